@@ -5,3 +5,8 @@ var client Client
 func SetClient(c Client) {
 	client = c
 }
+
+// GetClient returns the global client, used by the package-level helpers.
+func GetClient() Client {
+	return client
+}
@@ -0,0 +1,352 @@
+package asktest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"unicode/utf8"
+)
+
+// Mode selects how a Recorder uses its cassette.
+type Mode int
+
+const (
+	// ModeReplay answers from the cassette only; unmatched requests fail.
+	ModeReplay Mode = iota
+	// ModeRecord sends every request and overwrites the cassette.
+	ModeRecord
+	// ModeRecordMissing replays matched requests and records the others.
+	ModeRecordMissing
+	// ModePassthrough sends every request and records nothing.
+	ModePassthrough
+)
+
+// Doer is the interface of the client wrapped by a Recorder, satisfied by
+// *http.Client and by ask.HttpClient implementations.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Cassette is the file format of recorded interactions.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   Body        `json:"body,omitempty"`
+}
+
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       Body        `json:"body,omitempty"`
+}
+
+// Body is stored as text when it is valid UTF-8 and as base64 otherwise.
+type Body []byte
+
+func (body Body) MarshalJSON() ([]byte, error) {
+	if utf8.Valid(body) {
+		return json.Marshal(string(body))
+	}
+	return json.Marshal(map[string]string{"base64": base64.StdEncoding.EncodeToString(body)})
+}
+
+func (body *Body) UnmarshalJSON(data []byte) error {
+	var text string
+	if json.Unmarshal(data, &text) == nil {
+		*body = Body(text)
+		return nil
+	}
+
+	var encoded struct {
+		Base64 string `json:"base64"`
+	}
+	err := json.Unmarshal(data, &encoded)
+	if err != nil {
+		return err
+	}
+	*body, err = base64.StdEncoding.DecodeString(encoded.Base64)
+	return err
+}
+
+// Matcher reports whether an incoming request matches a recorded one. The
+// incoming request has already been through the recorder's redactions.
+type Matcher func(actual RecordedRequest, recorded RecordedRequest) bool
+
+func MatchMethod(actual RecordedRequest, recorded RecordedRequest) bool {
+	return actual.Method == recorded.Method
+}
+
+func MatchURL(actual RecordedRequest, recorded RecordedRequest) bool {
+	return actual.URL == recorded.URL
+}
+
+// MatchBody compares bodies, ignoring formatting and key order when both are
+// JSON.
+func MatchBody(actual RecordedRequest, recorded RecordedRequest) bool {
+	if bytes.Equal(actual.Body, recorded.Body) {
+		return true
+	}
+	a, errA := normalizeJson([]byte(actual.Body))
+	b, errB := normalizeJson([]byte(recorded.Body))
+	if errA != nil || errB != nil {
+		return false
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// Recorder records interactions sent through a wrapped client into a cassette
+// file and replays them, VCR-style. It is an HttpClient.
+type Recorder struct {
+	t         testing.TB
+	path      string
+	mode      Mode
+	inner     Doer
+	matchers  []Matcher
+	redactors []func(*Interaction)
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+	changed  bool
+}
+
+// NewRecorder loads the cassette at path, unless mode is ModeRecord, and saves
+// it when the test ends. A nil inner client uses http.DefaultClient.
+func NewRecorder(t testing.TB, path string, mode Mode, inner Doer) *Recorder {
+	t.Helper()
+	if inner == nil {
+		inner = http.DefaultClient
+	}
+
+	recorder := &Recorder{
+		t:        t,
+		path:     path,
+		mode:     mode,
+		inner:    inner,
+		matchers: []Matcher{MatchMethod, MatchURL},
+	}
+
+	if mode == ModeReplay || mode == ModeRecordMissing {
+		data, err := os.ReadFile(path)
+		switch {
+		case errors.Is(err, os.ErrNotExist) && mode == ModeRecordMissing:
+		case err != nil:
+			t.Fatalf("asktest: cannot read cassette: %v", err)
+		default:
+			if err := json.Unmarshal(data, &recorder.cassette); err != nil {
+				t.Fatalf("asktest: invalid cassette %s: %v", path, err)
+			}
+		}
+	}
+	recorder.used = make([]bool, len(recorder.cassette.Interactions))
+
+	t.Cleanup(func() {
+		if err := recorder.Save(); err != nil {
+			t.Errorf("asktest: cannot save cassette: %v", err)
+		}
+	})
+	return recorder
+}
+
+// MatchOn replaces the matchers used to find a recorded interaction. The
+// default matches on method and URL.
+func (recorder *Recorder) MatchOn(matchers ...Matcher) *Recorder {
+	recorder.matchers = matchers
+	return recorder
+}
+
+// Redact registers a function that scrubs secrets from interactions before
+// they are saved. It is also applied to incoming requests before matching.
+func (recorder *Recorder) Redact(redact func(*Interaction)) *Recorder {
+	recorder.redactors = append(recorder.redactors, redact)
+	return recorder
+}
+
+// RedactHeaders replaces the value of the given request and response headers.
+func (recorder *Recorder) RedactHeaders(names ...string) *Recorder {
+	return recorder.Redact(func(interaction *Interaction) {
+		for _, name := range names {
+			for _, header := range []http.Header{interaction.Request.Header, interaction.Response.Header} {
+				if header.Get(name) != "" {
+					header.Set(name, "REDACTED")
+				}
+			}
+		}
+	})
+}
+
+// RedactQuery replaces the value of the given query parameters.
+func (recorder *Recorder) RedactQuery(params ...string) *Recorder {
+	return recorder.Redact(func(interaction *Interaction) {
+		u, err := url.Parse(interaction.Request.URL)
+		if err != nil {
+			return
+		}
+		query := u.Query()
+		for _, param := range params {
+			if query.Has(param) {
+				query.Set(param, "REDACTED")
+			}
+		}
+		u.RawQuery = query.Encode()
+		interaction.Request.URL = u.String()
+	})
+}
+
+func (recorder *Recorder) Do(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	actual := Interaction{Request: RecordedRequest{
+		Method: req.Method,
+		URL:    req.URL.String(),
+		Header: req.Header.Clone(),
+		Body:   body,
+	}, Response: RecordedResponse{Header: http.Header{}}}
+	if actual.Request.Header == nil {
+		actual.Request.Header = http.Header{}
+	}
+	for _, redact := range recorder.redactors {
+		redact(&actual)
+	}
+
+	if recorder.mode == ModeReplay || recorder.mode == ModeRecordMissing {
+		if interaction, ok := recorder.find(actual.Request); ok {
+			return interaction.Response.toResponse(req), nil
+		}
+		if recorder.mode == ModeReplay {
+			err := fmt.Errorf("asktest: no recorded interaction matches %s %s", req.Method, actual.Request.URL)
+			recorder.t.Error(err)
+			return nil, err
+		}
+	}
+
+	response, err := recorder.inner.Do(req)
+	if err != nil || recorder.mode == ModePassthrough {
+		return response, err
+	}
+
+	responseBody, err := io.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	response.Body = io.NopCloser(bytes.NewReader(responseBody))
+
+	// The request half is already redacted; redacting again covers the response.
+	interaction := actual
+	interaction.Response = RecordedResponse{
+		StatusCode: response.StatusCode,
+		Header:     response.Header.Clone(),
+		Body:       responseBody,
+	}
+	if interaction.Response.Header == nil {
+		interaction.Response.Header = http.Header{}
+	}
+	for _, redact := range recorder.redactors {
+		redact(&interaction)
+	}
+
+	recorder.mu.Lock()
+	recorder.cassette.Interactions = append(recorder.cassette.Interactions, interaction)
+	recorder.used = append(recorder.used, true)
+	recorder.changed = true
+	recorder.mu.Unlock()
+
+	return response, nil
+}
+
+// find returns the first unused interaction matching request.
+func (recorder *Recorder) find(request RecordedRequest) (Interaction, bool) {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	for i, interaction := range recorder.cassette.Interactions {
+		if recorder.used[i] {
+			continue
+		}
+		matches := true
+		for _, matcher := range recorder.matchers {
+			if !matcher(request, interaction.Request) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			recorder.used[i] = true
+			return interaction, true
+		}
+	}
+	return Interaction{}, false
+}
+
+// Save writes the cassette if new interactions were recorded.
+func (recorder *Recorder) Save() error {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	if !recorder.changed && recorder.mode != ModeRecord {
+		return nil
+	}
+	if recorder.mode == ModeReplay || recorder.mode == ModePassthrough {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(recorder.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(recorder.path), 0755)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(recorder.path, append(data, '\n'), 0644)
+	if err != nil {
+		return err
+	}
+	recorder.changed = false
+	return nil
+}
+
+func (response RecordedResponse) toResponse(req *http.Request) *http.Response {
+	body := append([]byte(nil), response.Body...)
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", response.StatusCode, http.StatusText(response.StatusCode)),
+		StatusCode:    response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        response.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
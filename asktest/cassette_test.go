@@ -0,0 +1,111 @@
+package asktest_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hypnodev/ask"
+	"github.com/hypnodev/ask/asktest"
+	"github.com/stretchr/testify/assert"
+)
+
+// useRecorder sets the global client for the test, and restores the previous
+// one afterwards.
+func useRecorder(t *testing.T, recorder *asktest.Recorder) {
+	previous := ask.GetClient()
+	t.Cleanup(func() { ask.SetClient(previous) })
+
+	client := ask.NewClient(context.Background())
+	client.AddDefaultHeader("Authorization", "Bearer secret")
+	client.SetHttpClient(recorder)
+	ask.SetClient(*client)
+}
+
+func TestRecorderRecordAndReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=abc")
+		_, _ = w.Write([]byte(`{"id":1,"title":"` + r.Method + `"}`))
+	}))
+	cassette := filepath.Join(t.TempDir(), "fixtures", "posts.json")
+
+	t.Run("record", func(t *testing.T) {
+		recorder := asktest.NewRecorder(t, cassette, asktest.ModeRecord, nil).
+			RedactHeaders("Authorization", "Set-Cookie").
+			RedactQuery("token").
+			MatchOn(asktest.MatchMethod, asktest.MatchURL, asktest.MatchBody)
+		useRecorder(t, recorder)
+
+		var post Post
+		_, err := ask.GetJson(server.URL+"/posts/1?token=secret", &post)
+		assert.NoError(t, err)
+		_, err = ask.PostJson(server.URL+"/posts", []byte(`{"title":"New","userId":1}`), &post)
+		assert.NoError(t, err)
+		assert.Equal(t, "POST", post.Title)
+	})
+	server.Close()
+
+	data, err := os.ReadFile(cassette)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotContains(t, string(data), "secret")
+	assert.NotContains(t, string(data), "session=abc")
+	assert.Equal(t, 2, strings.Count(string(data), `"method"`))
+
+	t.Run("replay", func(t *testing.T) {
+		recorder := asktest.NewRecorder(t, cassette, asktest.ModeReplay, nil).
+			RedactHeaders("Authorization", "Set-Cookie").
+			RedactQuery("token").
+			MatchOn(asktest.MatchMethod, asktest.MatchURL, asktest.MatchBody)
+		useRecorder(t, recorder)
+
+		var post Post
+		res, err := ask.PostJson(server.URL+"/posts", []byte(`{"userId": 1, "title": "New"}`), &post)
+		assert.NoError(t, err)
+		assert.Equal(t, 200, res.StatusCode)
+		assert.Equal(t, "POST", post.Title)
+
+		_, err = ask.GetJson(server.URL+"/posts/1?token=other", &post)
+		assert.NoError(t, err)
+		assert.Equal(t, "GET", post.Title)
+	})
+
+	r := &recorder{}
+	replay := asktest.NewRecorder(r, cassette, asktest.ModeReplay, nil)
+	useRecorder(t, replay)
+	_, err = ask.GetJson(server.URL+"/posts/2", nil)
+	assert.Error(t, err)
+	r.finish()
+	assert.Len(t, r.errors, 1)
+}
+
+func TestRecorderRecordMissing(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		_, _ = w.Write([]byte{0xff, 0x00, 0x01})
+	}))
+	defer server.Close()
+	cassette := filepath.Join(t.TempDir(), "binary.json")
+
+	for i := 0; i < 2; i++ {
+		r := &recorder{}
+		recorder := asktest.NewRecorder(r, cassette, asktest.ModeRecordMissing, nil)
+		useRecorder(t, recorder)
+
+		var body []byte
+		_, err := ask.GetJson(server.URL, &body)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{0xff, 0x00, 0x01}, body)
+		r.finish()
+		assert.Empty(t, r.errors)
+	}
+
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,373 @@
+// Package asktest provides an HttpClient mock for testing code built on ask.
+package asktest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Call is a request received by a Mock.
+type Call struct {
+	Method     string
+	URL        *url.URL
+	Header     http.Header
+	Body       []byte
+	PathParams map[string]string
+}
+
+// Mock is an HttpClient that answers requests from registered routes. It is
+// safe for concurrent use.
+type Mock struct {
+	t      testing.TB
+	mu     sync.Mutex
+	routes []*Route
+	calls  []Call
+}
+
+// New returns a Mock whose expectations are verified when the test ends.
+func New(t testing.TB) *Mock {
+	mock := &Mock{t: t}
+	t.Cleanup(mock.Verify)
+	return mock
+}
+
+// On registers a route for method and a path pattern. Pattern segments like
+// {id} match a single segment, {path...} matches the rest of the path and *
+// matches any single segment. An empty method matches every method.
+func (mock *Mock) On(method string, pattern string) *Route {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+
+	route := &Route{
+		mock:     mock,
+		method:   strings.ToUpper(method),
+		pattern:  pattern,
+		query:    url.Values{},
+		header:   http.Header{},
+		status:   http.StatusOK,
+		response: http.Header{},
+		times:    -1,
+	}
+	mock.routes = append(mock.routes, route)
+	return route
+}
+
+func (mock *Mock) Do(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	call := Call{
+		Method: req.Method,
+		URL:    req.URL,
+		Header: req.Header.Clone(),
+		Body:   body,
+	}
+
+	mock.mu.Lock()
+	var exhausted *Route
+	var matched *Route
+	for _, route := range mock.routes {
+		params, ok := route.match(req, body)
+		if !ok {
+			continue
+		}
+		if route.times >= 0 && len(route.calls) >= route.times {
+			exhausted = route
+			continue
+		}
+		call.PathParams = params
+		route.calls = append(route.calls, call)
+		matched = route
+		break
+	}
+	mock.calls = append(mock.calls, call)
+	mock.mu.Unlock()
+
+	if matched == nil {
+		err := fmt.Errorf("asktest: no route matches %s %s", req.Method, req.URL)
+		if exhausted != nil {
+			err = fmt.Errorf("asktest: %s %s called more than %d times", exhausted.method, exhausted.pattern, exhausted.times)
+		}
+		mock.t.Error(err)
+		return nil, err
+	}
+	return matched.respond(req)
+}
+
+// Calls returns every request received, matched or not.
+func (mock *Mock) Calls() []Call {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]Call(nil), mock.calls...)
+}
+
+// Verify reports routes that were not called as expected. New registers it
+// with t.Cleanup.
+func (mock *Mock) Verify() {
+	mock.t.Helper()
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+
+	for _, route := range mock.routes {
+		switch {
+		case route.optional:
+		case route.times >= 0 && len(route.calls) != route.times:
+			mock.t.Errorf("asktest: expected %s %s to be called %d times, got %d", route.method, route.pattern, route.times, len(route.calls))
+		case route.times < 0 && len(route.calls) == 0:
+			mock.t.Errorf("asktest: expected %s %s to be called", route.method, route.pattern)
+		}
+	}
+}
+
+// Route matches requests and describes the response returned to them.
+type Route struct {
+	mock     *Mock
+	method   string
+	pattern  string
+	query    url.Values
+	header   http.Header
+	matchers []func(body []byte) bool
+
+	status   int
+	response http.Header
+	body     []byte
+	err      error
+
+	times    int
+	optional bool
+	calls    []Call
+}
+
+// WithQuery requires the query parameter key to have value.
+func (route *Route) WithQuery(key string, value string) *Route {
+	route.query.Add(key, value)
+	return route
+}
+
+// WithHeader requires the request header key to have value.
+func (route *Route) WithHeader(key string, value string) *Route {
+	route.header.Add(key, value)
+	return route
+}
+
+// WithBodyJson requires the request body to be JSON equal to v, regardless of
+// key order and formatting.
+func (route *Route) WithBodyJson(v any) *Route {
+	expected, err := normalizeJson(v)
+	if err != nil {
+		route.mock.t.Fatalf("asktest: invalid JSON body matcher: %v", err)
+	}
+	return route.WithBody(func(body []byte) bool {
+		var actual any
+		if json.Unmarshal(body, &actual) != nil {
+			return false
+		}
+		return reflect.DeepEqual(expected, actual)
+	})
+}
+
+// WithBody requires match to accept the request body.
+func (route *Route) WithBody(match func(body []byte) bool) *Route {
+	route.matchers = append(route.matchers, match)
+	return route
+}
+
+// Reply sets the response status code.
+func (route *Route) Reply(status int) *Route {
+	route.status = status
+	return route
+}
+
+// ReplyBody sets the response status code and body.
+func (route *Route) ReplyBody(status int, body string) *Route {
+	route.status = status
+	route.body = []byte(body)
+	return route
+}
+
+// ReplyJson sets the response status code and encodes v as its JSON body.
+func (route *Route) ReplyJson(status int, v any) *Route {
+	data, err := json.Marshal(v)
+	if err != nil {
+		route.mock.t.Fatalf("asktest: cannot encode response: %v", err)
+	}
+	route.status = status
+	route.body = data
+	route.response.Set("Content-Type", "application/json")
+	return route
+}
+
+// ReplyFile sets the response status code and uses the content of a fixture
+// file as body, with a Content-Type guessed from its extension.
+func (route *Route) ReplyFile(status int, path string) *Route {
+	route.mock.t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		route.mock.t.Fatalf("asktest: cannot read fixture: %v", err)
+	}
+	route.status = status
+	route.body = data
+	if contentType := mime.TypeByExtension(filepath.Ext(path)); contentType != "" {
+		route.response.Set("Content-Type", contentType)
+	}
+	return route
+}
+
+// ReplyHeader adds a response header.
+func (route *Route) ReplyHeader(key string, value string) *Route {
+	route.response.Add(key, value)
+	return route
+}
+
+// ReplyError makes the mock fail the request with err, like a network error.
+func (route *Route) ReplyError(err error) *Route {
+	route.err = err
+	return route
+}
+
+// Times expects the route to be called exactly n times. Further calls fail.
+func (route *Route) Times(n int) *Route {
+	route.times = n
+	return route
+}
+
+func (route *Route) Once() *Route {
+	return route.Times(1)
+}
+
+// Maybe lifts the expectation that the route is called at least once.
+func (route *Route) Maybe() *Route {
+	route.optional = true
+	return route
+}
+
+// Calls returns the requests matched by the route.
+func (route *Route) Calls() []Call {
+	route.mock.mu.Lock()
+	defer route.mock.mu.Unlock()
+	return append([]Call(nil), route.calls...)
+}
+
+func (route *Route) match(req *http.Request, body []byte) (map[string]string, bool) {
+	if route.method != "" && route.method != req.Method {
+		return nil, false
+	}
+	params, ok := matchPath(route.pattern, req.URL.Path)
+	if !ok {
+		return nil, false
+	}
+
+	query := req.URL.Query()
+	for key, values := range route.query {
+		for _, value := range values {
+			if !contains(query[key], value) {
+				return nil, false
+			}
+		}
+	}
+	for key, values := range route.header {
+		for _, value := range values {
+			if !contains(req.Header.Values(key), value) {
+				return nil, false
+			}
+		}
+	}
+	for _, matcher := range route.matchers {
+		if !matcher(body) {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+func (route *Route) respond(req *http.Request) (*http.Response, error) {
+	if route.err != nil {
+		return nil, route.err
+	}
+
+	body := append([]byte(nil), route.body...)
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", route.status, http.StatusText(route.status)),
+		StatusCode:    route.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        route.response.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+func matchPath(pattern string, path string) (map[string]string, bool) {
+	params := map[string]string{}
+	patternSegments := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+
+	for i, segment := range patternSegments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "...}") {
+			params[segment[1:len(segment)-4]] = strings.Join(pathSegments[min(i, len(pathSegments)):], "/")
+			return params, true
+		}
+		if i >= len(pathSegments) {
+			return nil, false
+		}
+		switch {
+		case segment == "*":
+		case strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}"):
+			if pathSegments[i] == "" {
+				return nil, false
+			}
+			params[segment[1:len(segment)-1]] = pathSegments[i]
+		case segment != pathSegments[i]:
+			return nil, false
+		}
+	}
+	return params, len(patternSegments) == len(pathSegments)
+}
+
+func normalizeJson(v any) (any, error) {
+	data, ok := v.([]byte)
+	if !ok {
+		if s, isString := v.(string); isString {
+			data = []byte(s)
+		} else {
+			var err error
+			data, err = json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var normalized any
+	err := json.Unmarshal(data, &normalized)
+	return normalized, err
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
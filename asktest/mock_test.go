@@ -0,0 +1,166 @@
+package asktest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/hypnodev/ask"
+	"github.com/hypnodev/ask/asktest"
+	"github.com/stretchr/testify/assert"
+)
+
+type Post struct {
+	Id     int    `json:"id,omitempty"`
+	Title  string `json:"title,omitempty"`
+	UserId int    `json:"userId"`
+}
+
+// recorder captures failures instead of failing the test.
+type recorder struct {
+	testing.TB
+	errors   []string
+	cleanups []func()
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Cleanup(fn func()) {
+	r.cleanups = append(r.cleanups, fn)
+}
+
+func (r *recorder) Error(args ...any) {
+	r.errors = append(r.errors, fmt.Sprint(args...))
+}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...any) {
+	r.Errorf(format, args...)
+}
+
+func (r *recorder) finish() {
+	for _, fn := range r.cleanups {
+		fn()
+	}
+}
+
+// useMock sets the global client for the test, and restores the previous one
+// afterwards.
+func useMock(t *testing.T, mock *asktest.Mock) {
+	previous := ask.GetClient()
+	t.Cleanup(func() { ask.SetClient(previous) })
+
+	client := ask.NewClient(context.Background())
+	client.SetBaseUrl("https://api.example.com")
+	client.SetHttpClient(mock)
+	ask.SetClient(*client)
+}
+
+func TestMockRoutes(t *testing.T) {
+	mock := asktest.New(t)
+	mock.On(http.MethodGet, "/posts/{id}").
+		WithQuery("expand", "user").
+		ReplyJson(http.StatusOK, Post{Id: 1, Title: "Mocked"}).
+		Once()
+	mock.On(http.MethodPost, "/posts").
+		WithHeader("Content-Type", "application/json").
+		WithBodyJson(`{"userId": 2, "title": "New"}`).
+		ReplyJson(http.StatusCreated, Post{Id: 2, Title: "New", UserId: 2})
+	mock.On(http.MethodDelete, "/posts/*").Reply(http.StatusNoContent).Maybe()
+
+	useMock(t, mock)
+
+	var post Post
+	res, err := ask.GetJson("/posts/1?expand=user", &post)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "Mocked", post.Title)
+
+	res, err = ask.PostJson("/posts", []byte(`{"title":"New","userId":2}`), &post)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+	assert.Equal(t, 2, post.Id)
+
+	calls := mock.Calls()
+	assert.Len(t, calls, 2)
+	assert.Equal(t, "application/json", calls[0].Header.Get("Accept"))
+	assert.Equal(t, map[string]string{"id": "1"}, calls[0].PathParams)
+}
+
+func TestMockFixtureAndErrors(t *testing.T) {
+	fixture := filepath.Join(t.TempDir(), "post.json")
+	_ = os.WriteFile(fixture, []byte(`{"id":7,"title":"From fixture"}`), 0644)
+
+	mock := asktest.New(t)
+	mock.On("", "/files/{path...}").ReplyFile(http.StatusOK, fixture)
+	mock.On(http.MethodGet, "/down").ReplyError(errors.New("connection refused"))
+
+	useMock(t, mock)
+
+	var post Post
+	_, err := ask.GetJson("/files/a/b/post.json", &post)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 7, post.Id)
+
+	_, err = ask.GetJson("/down", &post)
+	assert.EqualError(t, err, "connection refused")
+}
+
+func TestMockVerify(t *testing.T) {
+	r := &recorder{}
+	mock := asktest.New(r)
+	mock.On(http.MethodGet, "/never")
+	mock.On(http.MethodGet, "/twice").Times(2)
+	mock.On(http.MethodGet, "/once").Once()
+
+	useMock(t, mock)
+	_, _ = ask.GetJson("/twice", nil)
+	_, _ = ask.GetJson("/once", nil)
+	_, err := ask.GetJson("/once", nil)
+	assert.Error(t, err)
+	_, err = ask.GetJson("/unknown", nil)
+	assert.Error(t, err)
+
+	r.finish()
+	assert.Equal(t, []string{
+		"asktest: GET /once called more than 1 times",
+		"asktest: no route matches GET https://api.example.com/unknown",
+		"asktest: expected GET /never to be called",
+		"asktest: expected GET /twice to be called 2 times, got 1",
+	}, r.errors)
+}
+
+func TestMockConcurrentCalls(t *testing.T) {
+	mock := asktest.New(t)
+	route := mock.On(http.MethodGet, "/posts/{id}").ReplyJson(http.StatusOK, Post{}).Times(20)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("https://api.example.com/posts/%d", i), nil)
+			res, err := mock.Do(req)
+			if assert.NoError(t, err) {
+				res.Body.Close()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Len(t, route.Calls(), 20)
+}
@@ -0,0 +1,153 @@
+package ask
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// limitedBody fails with a BodyTooLargeError once more than limit bytes have
+// been read, instead of silently truncating like io.LimitReader.
+type limitedBody struct {
+	reader    io.Reader
+	closers   []io.Closer
+	limit     int64
+	remaining int64
+}
+
+func (body *limitedBody) Read(p []byte) (int, error) {
+	if body.limit <= 0 {
+		return body.reader.Read(p)
+	}
+
+	if body.remaining <= 0 {
+		var probe [1]byte
+		n, err := body.reader.Read(probe[:])
+		if n > 0 {
+			return 0, &BodyTooLargeError{Limit: body.limit}
+		}
+		return 0, err
+	}
+
+	if int64(len(p)) > body.remaining {
+		p = p[:body.remaining]
+	}
+	n, err := body.reader.Read(p)
+	body.remaining -= int64(n)
+	return n, err
+}
+
+func (body *limitedBody) Close() error {
+	var err error
+	for i := len(body.closers) - 1; i >= 0; i-- {
+		err = errors.Join(err, body.closers[i].Close())
+	}
+	return err
+}
+
+func (request *Request) maxBodySize() int64 {
+	if request.maxResponseSize != 0 {
+		return request.maxResponseSize
+	}
+	return request.client.maxResponseSize
+}
+
+// responseBody returns the decoded response body. Encodings the transport left
+// in place are decompressed here, and the size limit is applied to the
+// decompressed bytes so a small compressed payload cannot expand without bound.
+func (request *Request) responseBody(response *http.Response) (io.ReadCloser, error) {
+	return decodedBody(response, request.maxBodySize())
+}
+
+func decodedBody(response *http.Response, limit int64) (io.ReadCloser, error) {
+	body := &limitedBody{reader: response.Body, closers: []io.Closer{response.Body}, limit: limit, remaining: limit}
+
+	encoding := strings.ToLower(strings.TrimSpace(response.Header.Get("Content-Encoding")))
+	if response.Uncompressed || encoding == "" || encoding == "identity" {
+		if limit > 0 && response.ContentLength > limit {
+			return nil, &BodyTooLargeError{Limit: limit}
+		}
+		return body, nil
+	}
+
+	switch encoding {
+	case "gzip", "x-gzip":
+		reader, err := gzip.NewReader(response.Body)
+		if err != nil {
+			return nil, err
+		}
+		body.reader = reader
+		body.closers = append(body.closers, reader)
+	case "deflate":
+		reader, err := zlib.NewReader(response.Body)
+		if err != nil {
+			return nil, err
+		}
+		body.reader = reader
+		body.closers = append(body.closers, reader)
+	}
+	return body, nil
+}
+
+// decodeStream decodes body into v, without buffering it when the codec
+// supports streaming.
+func decodeStream(client *Client, contentType string, body io.Reader, v any) error {
+	codec := client.codec(contentType)
+	if streamCodec, ok := codec.(StreamCodec); ok {
+		if _, raw := v.(*[]byte); !raw {
+			err := streamCodec.Decode(body, v)
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	return decodeBody(client, contentType, data, v)
+}
+
+// saveToFile writes a successful response body to dest. Other responses go
+// through the status handling of the request, and leave dest untouched.
+func saveToFile(request *Request, dest string) error {
+	response, err := request.SendRaw()
+	if err != nil {
+		return err
+	}
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		_, err = request.receive(response, nil)
+		return err
+	}
+	if response.Body == nil {
+		response.Body = http.NoBody
+	}
+	defer response.Body.Close()
+
+	body, err := request.responseBody(response)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	file, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(file, body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		// Do not leave a truncated file behind.
+		os.Remove(dest)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package ask
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxResponseSize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Has("chunked") {
+			w.(http.Flusher).Flush()
+		}
+		_, _ = w.Write([]byte(`{"title":"` + strings.Repeat("a", 2048) + `"}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(context.Background())
+	c.SetMaxResponseSize(1024)
+	useClient(t, c)
+
+	var post Post
+	_, err := GetJson(server.URL, &post)
+	assert.True(t, errors.Is(err, ErrBodyTooLarge))
+
+	_, err = GetJson(server.URL+"?chunked", &post)
+	var tooLarge *BodyTooLargeError
+	assert.True(t, errors.As(err, &tooLarge))
+	assert.Equal(t, int64(1024), tooLarge.Limit)
+
+	request := NewRequest(http.MethodGet, server.URL)
+	request.setClient(c)
+	_, err = request.WithMaxResponseSize(-1).SendInto(&post)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, post.Title, 2048)
+}
+
+func TestMaxResponseSizeDecompressionBomb(t *testing.T) {
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	_, _ = writer.Write(bytes.Repeat([]byte(" "), 10<<20))
+	_ = writer.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(compressed.Bytes())
+	}))
+	defer server.Close()
+
+	c := NewClient(context.Background())
+	c.SetMaxResponseSize(1 << 20)
+
+	for _, acceptEncoding := range []string{"", "gzip"} {
+		request := NewRequest(http.MethodGet, server.URL)
+		request.setClient(c)
+		if acceptEncoding != "" {
+			request.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+
+		var v any
+		_, err := request.SendInto(&v)
+		assert.True(t, errors.Is(err, ErrBodyTooLarge), "Accept-Encoding %q: %v", acceptEncoding, err)
+	}
+	assert.Less(t, compressed.Len(), 1<<20)
+}
+
+func TestGetFileStreamsToDisk(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("file content"))
+	}))
+	defer server.Close()
+
+	useClient(t, NewClient(context.Background()))
+	dest := filepath.Join(t.TempDir(), "download.txt")
+
+	err := GetFile(server.URL, dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(dest)
+	assert.Equal(t, "file content", string(data))
+}
+
+func TestDecodedBodyNotKept(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"title":"post"}`))
+	}))
+	defer server.Close()
+	c := NewClient(context.Background())
+
+	var post Post
+	res, err := c.NewRequest(http.MethodGet, server.URL).SendInto(&post)
+	assert.NoError(t, err)
+	assert.Equal(t, "post", post.Title)
+	assert.Nil(t, res.GetBody())
+	assert.Empty(t, res.Text())
+
+	res, err = c.NewRequest(http.MethodGet, server.URL).Send()
+	assert.NoError(t, err)
+	assert.Equal(t, `{"title":"post"}`, string(*res.GetBody()))
+	assert.NoError(t, res.Decode(&post))
+}
+
+func TestGetFileFailures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(strings.Repeat("a", 2048)))
+	}))
+	defer server.Close()
+	dir := t.TempDir()
+
+	c := NewClient(context.Background())
+	c.SetMaxResponseSize(1024)
+	dest := filepath.Join(dir, "large.txt")
+	err := saveToFile(c.NewRequest(http.MethodGet, server.URL), dest)
+	assert.ErrorIs(t, err, ErrBodyTooLarge)
+	assert.NoFileExists(t, dest)
+
+	dest = filepath.Join(dir, "missing.txt")
+	assert.NoError(t, os.WriteFile(dest, []byte("previous"), 0644))
+	err = saveToFile(c.NewRequest(http.MethodGet, server.URL+"/missing").Expect(http.StatusOK), dest)
+	var statusErr *StatusError
+	assert.True(t, errors.As(err, &statusErr))
+	assert.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+	data, _ := os.ReadFile(dest)
+	assert.Equal(t, "previous", string(data))
+
+	request := NewClient(context.Background()).NewRequest(http.MethodGet, server.URL)
+	err = saveToFile(request, filepath.Join(dir, "none", "file.txt"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -9,25 +9,70 @@ type HttpClient interface {
 	Do(req *http.Request) (*http.Response, error)
 }
 
+// RateLimiter is consulted before every request sent through a Client.
+// It is satisfied by *rate.Limiter from golang.org/x/time/rate.
+type RateLimiter interface {
+	Wait(ctx context.Context) error
+}
+
 type Client struct {
-	ctx            context.Context
-	httpClient     HttpClient
-	baseUrl        string
+	ctx        context.Context
+	httpClient HttpClient
+	baseUrl    string
+	// socketClient dials socketPath, set by a unix:// base URL.
+	socketPath     string
+	socketClient   HttpClient
 	defaultHeaders http.Header
 	verbose        bool
+	rateLimiter    RateLimiter
+	codecs         map[string]Codec
+	defaultCodec   Codec
+
+	maxResponseSize int64
+	dedup           *dedupGroup
+	hedgePolicy     *HedgePolicy
+	hedging         *hedger
+	metrics         Metrics
+	tracer          Tracer
+	requestIdHeader string
+	endpoints       *endpointPool
+	redirectPolicy  *RedirectPolicy
+	status          statusRules
 }
 
-func NewClient(ctx context.Context) *Client {
-	httpClient := &http.Client{}
+// NewClient returns a client using the default transport, or its own
+// transport configured by opts.
+func NewClient(ctx context.Context, opts ...ClientOption) *Client {
+	var httpClient HttpClient = &http.Client{}
+	if len(opts) > 0 {
+		config := newTransportConfig()
+		for _, opt := range opts {
+			opt(config)
+		}
+		httpClient = config.build()
+	}
 
 	return &Client{
 		ctx:            ctx,
 		httpClient:     httpClient,
 		defaultHeaders: http.Header{},
+		hedging:        newHedger(),
 	}
 }
 
+// SetBaseUrl prefixes relative request URLs. A "unix:///path/to.sock" base
+// URL sends requests over that Unix domain socket, with "localhost" as host.
+// It replaces the base URLs set with SetBaseUrls.
 func (client *Client) SetBaseUrl(url string) Client {
+	if client.endpoints != nil {
+		client.endpoints = &endpointPool{maxFailures: client.endpoints.maxFailures, cooldown: client.endpoints.cooldown}
+	}
+	client.socketPath, client.socketClient = "", nil
+	if path, ok := unixSocketPath(url); ok {
+		client.socketPath = path
+		client.socketClient = unixSocketClient(client.httpClient, path)
+		url = "http://localhost"
+	}
 	client.baseUrl = url
 	return *client
 }
@@ -41,3 +86,35 @@ func (client *Client) SetVerbose(flag bool) Client {
 	client.verbose = flag
 	return *client
 }
+
+func (client *Client) SetRateLimiter(limiter RateLimiter) Client {
+	client.rateLimiter = limiter
+	return *client
+}
+
+// SetMaxResponseSize limits the size of decoded response bodies read by Send,
+// after any decompression. Larger bodies fail with ErrBodyTooLarge. Zero
+// means no limit.
+func (client *Client) SetMaxResponseSize(n int64) Client {
+	client.maxResponseSize = n
+	return *client
+}
+
+// SetHttpClient replaces the client used to send requests, e.g. with a mock
+// from the asktest package.
+func (client *Client) SetHttpClient(httpClient HttpClient) Client {
+	client.httpClient = httpClient
+	if client.socketPath != "" {
+		client.socketClient = unixSocketClient(httpClient, client.socketPath)
+	}
+	return *client
+}
+
+// sender returns the client sending requests, the one dialing the socket of a
+// unix:// base URL if any.
+func (client *Client) sender() HttpClient {
+	if client.socketPath != "" {
+		return client.socketClient
+	}
+	return client.httpClient
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
@@ -50,3 +52,22 @@ func TestClientPrintBody(t *testing.T) {
 	assert.Equal(t, 200, res.StatusCode)
 	assert.Equal(t, "Test title", post.Title)
 }
+
+func TestClientBaseUrlSkipsAbsoluteUrls(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.Host+r.URL.Path)
+	}))
+	defer server.Close()
+
+	client := NewClient(context.Background())
+	client.SetBaseUrl(server.URL + "/api")
+
+	_, err := client.NewRequest(http.MethodGet, "/posts").Send()
+	assert.NoError(t, err)
+	_, err = client.NewRequest(http.MethodGet, server.URL+"/other").Send()
+	assert.NoError(t, err)
+
+	host := server.Listener.Addr().String()
+	assert.Equal(t, []string{host + "/api/posts", host + "/other"}, paths)
+}
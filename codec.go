@@ -0,0 +1,167 @@
+package ask
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"mime"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Codec encodes request payloads and decodes response bodies for a media type.
+type Codec interface {
+	ContentType() string
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// StreamCodec is implemented by codecs that can decode directly from a reader,
+// which SendInto uses to avoid buffering response bodies.
+type StreamCodec interface {
+	Codec
+	Decode(r io.Reader, v any) error
+}
+
+type JsonCodec struct{}
+
+func (JsonCodec) ContentType() string {
+	return "application/json"
+}
+
+func (JsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+func (JsonCodec) Decode(r io.Reader, v any) error {
+	return json.NewDecoder(r).Decode(v)
+}
+
+type XmlCodec struct{}
+
+func (XmlCodec) ContentType() string {
+	return "application/xml"
+}
+
+func (XmlCodec) Marshal(v any) ([]byte, error) {
+	return xml.Marshal(v)
+}
+
+func (XmlCodec) Unmarshal(data []byte, v any) error {
+	return xml.Unmarshal(data, v)
+}
+
+func (XmlCodec) Decode(r io.Reader, v any) error {
+	return xml.NewDecoder(r).Decode(v)
+}
+
+type YamlCodec struct{}
+
+func (YamlCodec) ContentType() string {
+	return "application/yaml"
+}
+
+func (YamlCodec) Marshal(v any) ([]byte, error) {
+	return yaml.Marshal(v)
+}
+
+func (YamlCodec) Unmarshal(data []byte, v any) error {
+	return yaml.Unmarshal(data, v)
+}
+
+func (YamlCodec) Decode(r io.Reader, v any) error {
+	return yaml.NewDecoder(r).Decode(v)
+}
+
+var defaultCodecs = map[string]Codec{
+	"application/json":   JsonCodec{},
+	"text/json":          JsonCodec{},
+	"application/xml":    XmlCodec{},
+	"text/xml":           XmlCodec{},
+	"application/yaml":   YamlCodec{},
+	"application/x-yaml": YamlCodec{},
+	"text/yaml":          YamlCodec{},
+	"text/csv":           CsvCodec{},
+
+	"application/msgpack":     MsgpackCodec{},
+	"application/x-msgpack":   MsgpackCodec{},
+	"application/vnd.msgpack": MsgpackCodec{},
+	"application/cbor":        CborCodec{},
+}
+
+// RegisterCodec makes the client encode and decode codec.ContentType() bodies
+// with codec. Extra media types can be mapped to the same codec with aliases.
+func (client *Client) RegisterCodec(codec Codec, aliases ...string) Client {
+	if client.codecs == nil {
+		client.codecs = map[string]Codec{}
+	}
+	client.codecs[mediaType(codec.ContentType())] = codec
+	for _, alias := range aliases {
+		client.codecs[mediaType(alias)] = codec
+	}
+	return *client
+}
+
+// SetDefaultCodec sets the codec used when a response has no Content-Type or
+// one without a registered codec.
+func (client *Client) SetDefaultCodec(codec Codec) Client {
+	client.defaultCodec = codec
+	return *client
+}
+
+// lookupCodec returns the codec registered for contentType, or nil when there
+// is none.
+func (client *Client) lookupCodec(contentType string) Codec {
+	media := mediaType(contentType)
+	if media == "" {
+		return nil
+	}
+
+	if client != nil {
+		if codec, ok := client.codecs[media]; ok {
+			return codec
+		}
+	}
+	if codec, ok := defaultCodecs[media]; ok {
+		return codec
+	}
+
+	// Structured syntax suffixes, e.g. application/problem+json.
+	if i := strings.LastIndexByte(media, '+'); i >= 0 {
+		switch media[i+1:] {
+		case "json":
+			return client.lookupCodec("application/json")
+		case "xml":
+			return client.lookupCodec("application/xml")
+		case "yaml":
+			return client.lookupCodec("application/yaml")
+		case "cbor":
+			return client.lookupCodec("application/cbor")
+		}
+	}
+	return nil
+}
+
+// codec returns the codec for contentType, falling back to the default codec.
+func (client *Client) codec(contentType string) Codec {
+	if codec := client.lookupCodec(contentType); codec != nil {
+		return codec
+	}
+	if client != nil && client.defaultCodec != nil {
+		return client.defaultCodec
+	}
+	return JsonCodec{}
+}
+
+func mediaType(contentType string) string {
+	media, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(contentType))
+	}
+	return media
+}
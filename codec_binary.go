@@ -0,0 +1,514 @@
+package ask
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxDecodeDepth bounds the nesting of arrays and maps accepted by the binary
+// decoders, so a hostile body cannot exhaust the stack.
+const maxDecodeDepth = 512
+
+var errUnexpectedEnd = errors.New("ask: unexpected end of data")
+
+// binaryEncoder is implemented by the MessagePack and CBOR writers, which share
+// the reflection walk in encodeBinary.
+type binaryEncoder interface {
+	encodeNil()
+	encodeBool(b bool)
+	encodeInt(n int64)
+	encodeUint(n uint64)
+	encodeFloat32(f float32)
+	encodeFloat64(f float64)
+	encodeString(s string)
+	encodeBytes(b []byte)
+	encodeArrayHeader(n int)
+	encodeMapHeader(n int)
+	encodeTime(t time.Time)
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func encodeBinary(enc binaryEncoder, tagName string, v reflect.Value) error {
+	if !v.IsValid() {
+		enc.encodeNil()
+		return nil
+	}
+	if v.Type() == timeType {
+		enc.encodeTime(v.Interface().(time.Time))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			enc.encodeNil()
+			return nil
+		}
+		return encodeBinary(enc, tagName, v.Elem())
+	case reflect.Bool:
+		enc.encodeBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		enc.encodeInt(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		enc.encodeUint(v.Uint())
+	case reflect.Float32:
+		enc.encodeFloat32(float32(v.Float()))
+	case reflect.Float64:
+		enc.encodeFloat64(v.Float())
+	case reflect.String:
+		enc.encodeString(v.String())
+	case reflect.Slice:
+		if v.IsNil() {
+			enc.encodeNil()
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			enc.encodeBytes(v.Bytes())
+			return nil
+		}
+		return encodeBinaryArray(enc, tagName, v)
+	case reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(b), v)
+			enc.encodeBytes(b)
+			return nil
+		}
+		return encodeBinaryArray(enc, tagName, v)
+	case reflect.Map:
+		if v.IsNil() {
+			enc.encodeNil()
+			return nil
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			if keys[i].Kind() == reflect.String && keys[j].Kind() == reflect.String {
+				return keys[i].String() < keys[j].String()
+			}
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		enc.encodeMapHeader(len(keys))
+		for _, key := range keys {
+			if err := encodeBinary(enc, tagName, key); err != nil {
+				return err
+			}
+			if err := encodeBinary(enc, tagName, v.MapIndex(key)); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		var values []reflect.Value
+		var names []string
+		for _, field := range binaryFields(v.Type(), tagName) {
+			value, ok := fieldByIndex(v, field.index)
+			if !ok || (field.omitEmpty && isEmptyValue(value)) {
+				continue
+			}
+			names = append(names, field.name)
+			values = append(values, value)
+		}
+		enc.encodeMapHeader(len(values))
+		for i, value := range values {
+			enc.encodeString(names[i])
+			if err := encodeBinary(enc, tagName, value); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("ask: cannot encode %s", v.Type())
+	}
+	return nil
+}
+
+func encodeBinaryArray(enc binaryEncoder, tagName string, v reflect.Value) error {
+	enc.encodeArrayHeader(v.Len())
+	for i := 0; i < v.Len(); i++ {
+		if err := encodeBinary(enc, tagName, v.Index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type binaryField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+type binaryFieldsKey struct {
+	t       reflect.Type
+	tagName string
+}
+
+var binaryFieldsCache sync.Map
+
+// binaryFields lists the encodable fields of t. The name comes from the
+// tagName tag, then the json tag, then the field name; untagged embedded
+// structs are flattened like encoding/json does.
+func binaryFields(t reflect.Type, tagName string) []binaryField {
+	key := binaryFieldsKey{t: t, tagName: tagName}
+	if cached, ok := binaryFieldsCache.Load(key); ok {
+		return cached.([]binaryField)
+	}
+
+	var fields []binaryField
+	seen := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, ok := field.Tag.Lookup(tagName)
+		if !ok {
+			tag = field.Tag.Get("json")
+		}
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			for _, embedded := range binaryFields(fieldType, tagName) {
+				if seen[embedded.name] {
+					continue
+				}
+				seen[embedded.name] = true
+				embedded.index = append([]int{i}, embedded.index...)
+				fields = append(fields, embedded)
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		seen[name] = true
+		fields = append(fields, binaryField{
+			name:      name,
+			index:     []int{i},
+			omitEmpty: strings.Contains(","+options+",", ",omitempty,"),
+		})
+	}
+
+	binaryFieldsCache.Store(key, fields)
+	return fields
+}
+
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	}
+	return false
+}
+
+// unmarshalBinary assigns a value produced by one of the binary decoders to
+// the value v points to.
+func unmarshalBinary(decoded any, tagName string, v any) error {
+	target := reflect.ValueOf(v)
+	if target.Kind() != reflect.Pointer || target.IsNil() {
+		return fmt.Errorf("ask: cannot decode into %T", v)
+	}
+	return assignDecoded(target.Elem(), decoded, tagName)
+}
+
+func assignDecoded(dst reflect.Value, value any, tagName string) error {
+	switch tagged := value.(type) {
+	case CborTag:
+		if dst.Kind() != reflect.Interface {
+			return assignDecoded(dst, tagged.Content, tagName)
+		}
+	case MsgpackExtension:
+		if dst.Kind() != reflect.Interface && dst.Type() != reflect.TypeOf(tagged) {
+			return fmt.Errorf("ask: cannot decode msgpack extension %d into %s", tagged.Type, dst.Type())
+		}
+	}
+
+	if dst.Kind() == reflect.Pointer {
+		if value == nil {
+			dst.Set(reflect.Zero(dst.Type()))
+			return nil
+		}
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return assignDecoded(dst.Elem(), value, tagName)
+	}
+	if value == nil {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+	if dst.Kind() == reflect.Interface && dst.NumMethod() == 0 {
+		dst.Set(reflect.ValueOf(value))
+		return nil
+	}
+
+	src := reflect.ValueOf(value)
+	if src.Type().AssignableTo(dst.Type()) {
+		dst.Set(src)
+		return nil
+	}
+	if dst.Type() == timeType {
+		switch t := value.(type) {
+		case string:
+			parsed, err := time.Parse(time.RFC3339Nano, t)
+			if err != nil {
+				return err
+			}
+			dst.Set(reflect.ValueOf(parsed))
+			return nil
+		case int64:
+			dst.Set(reflect.ValueOf(time.Unix(t, 0)))
+			return nil
+		case uint64:
+			dst.Set(reflect.ValueOf(time.Unix(int64(t), 0)))
+			return nil
+		case float64:
+			sec, frac := math.Modf(t)
+			dst.Set(reflect.ValueOf(time.Unix(int64(sec), int64(frac*1e9))))
+			return nil
+		}
+	}
+
+	mismatch := fmt.Errorf("ask: cannot decode %T into %s", value, dst.Type())
+	switch dst.Kind() {
+	case reflect.Bool:
+		b, ok := value.(bool)
+		if !ok {
+			return mismatch
+		}
+		dst.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		switch number := value.(type) {
+		case int64:
+			n = number
+		case uint64:
+			if number > math.MaxInt64 {
+				return fmt.Errorf("ask: %d overflows %s", number, dst.Type())
+			}
+			n = int64(number)
+		default:
+			return mismatch
+		}
+		if dst.OverflowInt(n) {
+			return fmt.Errorf("ask: %d overflows %s", n, dst.Type())
+		}
+		dst.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var n uint64
+		switch number := value.(type) {
+		case uint64:
+			n = number
+		case int64:
+			if number < 0 {
+				return fmt.Errorf("ask: %d overflows %s", number, dst.Type())
+			}
+			n = uint64(number)
+		default:
+			return mismatch
+		}
+		if dst.OverflowUint(n) {
+			return fmt.Errorf("ask: %d overflows %s", n, dst.Type())
+		}
+		dst.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		switch number := value.(type) {
+		case float64:
+			dst.SetFloat(number)
+		case float32:
+			dst.SetFloat(float64(number))
+		case int64:
+			dst.SetFloat(float64(number))
+		case uint64:
+			dst.SetFloat(float64(number))
+		default:
+			return mismatch
+		}
+	case reflect.String:
+		switch s := value.(type) {
+		case string:
+			dst.SetString(s)
+		case []byte:
+			dst.SetString(string(s))
+		default:
+			return mismatch
+		}
+	case reflect.Slice:
+		if dst.Type().Elem().Kind() == reflect.Uint8 {
+			switch b := value.(type) {
+			case []byte:
+				dst.SetBytes(append([]byte(nil), b...))
+				return nil
+			case string:
+				dst.SetBytes([]byte(b))
+				return nil
+			}
+		}
+		items, ok := value.([]any)
+		if !ok {
+			return mismatch
+		}
+		slice := reflect.MakeSlice(dst.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := assignDecoded(slice.Index(i), item, tagName); err != nil {
+				return err
+			}
+		}
+		dst.Set(slice)
+	case reflect.Array:
+		if b, ok := value.([]byte); ok && dst.Type().Elem().Kind() == reflect.Uint8 {
+			reflect.Copy(dst, reflect.ValueOf(b))
+			return nil
+		}
+		items, ok := value.([]any)
+		if !ok {
+			return mismatch
+		}
+		for i := 0; i < dst.Len() && i < len(items); i++ {
+			if err := assignDecoded(dst.Index(i), items[i], tagName); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if dst.IsNil() {
+			dst.Set(reflect.MakeMap(dst.Type()))
+		}
+		return forEachDecodedEntry(value, mismatch, func(key any, item any) error {
+			k := reflect.New(dst.Type().Key()).Elem()
+			if err := assignDecoded(k, key, tagName); err != nil {
+				return err
+			}
+			v := reflect.New(dst.Type().Elem()).Elem()
+			if err := assignDecoded(v, item, tagName); err != nil {
+				return err
+			}
+			dst.SetMapIndex(k, v)
+			return nil
+		})
+	case reflect.Struct:
+		fields := binaryFields(dst.Type(), tagName)
+		return forEachDecodedEntry(value, mismatch, func(key any, item any) error {
+			name, ok := key.(string)
+			if !ok {
+				return nil
+			}
+			field := lookupBinaryField(fields, name)
+			if field == nil {
+				return nil
+			}
+			target := dst
+			for i, x := range field.index {
+				if i > 0 && target.Kind() == reflect.Pointer {
+					if target.IsNil() {
+						target.Set(reflect.New(target.Type().Elem()))
+					}
+					target = target.Elem()
+				}
+				target = target.Field(x)
+			}
+			return assignDecoded(target, item, tagName)
+		})
+	default:
+		return mismatch
+	}
+	return nil
+}
+
+func forEachDecodedEntry(value any, mismatch error, fn func(key any, item any) error) error {
+	switch entries := value.(type) {
+	case map[string]any:
+		for key, item := range entries {
+			if err := fn(key, item); err != nil {
+				return err
+			}
+		}
+	case map[any]any:
+		for key, item := range entries {
+			if err := fn(key, item); err != nil {
+				return err
+			}
+		}
+	default:
+		return mismatch
+	}
+	return nil
+}
+
+func lookupBinaryField(fields []binaryField, name string) *binaryField {
+	for i := range fields {
+		if fields[i].name == name {
+			return &fields[i]
+		}
+	}
+	for i := range fields {
+		if strings.EqualFold(fields[i].name, name) {
+			return &fields[i]
+		}
+	}
+	return nil
+}
+
+// newDecodedMap returns a map[string]any when every key is a string, as most
+// payloads are keyed by field names, and a map[any]any otherwise.
+func newDecodedMap(keys []any, values []any) (any, error) {
+	strKeys := true
+	for _, key := range keys {
+		if _, ok := key.(string); !ok {
+			strKeys = false
+			break
+		}
+	}
+
+	if strKeys {
+		m := make(map[string]any, len(keys))
+		for i, key := range keys {
+			m[key.(string)] = values[i]
+		}
+		return m, nil
+	}
+
+	m := make(map[any]any, len(keys))
+	for i, key := range keys {
+		if key != nil && !reflect.TypeOf(key).Comparable() {
+			return nil, fmt.Errorf("ask: unsupported map key of type %T", key)
+		}
+		m[key] = values[i]
+	}
+	return m, nil
+}
@@ -0,0 +1,201 @@
+package ask
+
+import (
+	"encoding/hex"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type Reading struct {
+	Sensor string            `json:"sensor" msgpack:"s" cbor:"s"`
+	Value  float64           `json:"value"`
+	Tags   map[string]string `json:"tags,omitempty"`
+	Raw    []byte            `json:"raw,omitempty"`
+	At     time.Time         `json:"at"`
+	Skip   string            `json:"-"`
+}
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestMsgpackVectors(t *testing.T) {
+	cases := []struct {
+		value any
+		hex   string
+	}{
+		{nil, "c0"},
+		{true, "c3"},
+		{7, "07"},
+		{-5, "fb"},
+		{-33, "d0df"},
+		{128, "cc80"},
+		{-129, "d1ff7f"},
+		{65536, "ce00010000"},
+		{uint64(math.MaxUint64), "cfffffffffffffffff"},
+		{1.5, "cb3ff8000000000000"},
+		{float32(1.5), "ca3fc00000"},
+		{"hello", "a568656c6c6f"},
+		{[]byte{1, 2}, "c4020102"},
+		{[]int{1, 2, 3}, "93010203"},
+		{map[string]any{"compact": true, "schema": 0}, "82a7636f6d70616374c3a6736368656d6100"},
+		{time.Unix(1, 0), "d6ff00000001"},
+	}
+
+	for _, c := range cases {
+		data, err := MsgpackCodec{}.Marshal(c.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, c.hex, hex.EncodeToString(data), "%v", c.value)
+	}
+
+	var decoded any
+	err := MsgpackCodec{}.Unmarshal(mustHex(t, "82a7636f6d70616374c3a6736368656d6100"), &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, map[string]any{"compact": true, "schema": int64(0)}, decoded)
+
+	var at time.Time
+	err = MsgpackCodec{}.Unmarshal(mustHex(t, "d7ff000000040000000a"), &at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, time.Unix(10, 1).Equal(at))
+}
+
+func TestCborVectors(t *testing.T) {
+	cases := []struct {
+		value any
+		hex   string
+	}{
+		{0, "00"},
+		{23, "17"},
+		{24, "1818"},
+		{1000, "1903e8"},
+		{1000000000000, "1b000000e8d4a51000"},
+		{uint64(math.MaxUint64), "1bffffffffffffffff"},
+		{-1, "20"},
+		{-1000, "3903e7"},
+		{1.1, "fb3ff199999999999a"},
+		{100000.0, "fa47c35000"},
+		{false, "f4"},
+		{nil, "f6"},
+		{"IETF", "6449455446"},
+		{[]byte{1, 2, 3, 4}, "4401020304"},
+		{[]any{1, []int{2, 3}, []int{4, 5}}, "8301820203820405"},
+		{map[string]any{"a": 1, "b": []int{2, 3}}, "a26161016162820203"},
+		{time.Date(2013, 3, 21, 20, 4, 0, 0, time.UTC), "c074323031332d30332d32315432303a30343a30305a"},
+	}
+
+	for _, c := range cases {
+		data, err := CborCodec{}.Marshal(c.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, c.hex, hex.EncodeToString(data), "%v", c.value)
+	}
+
+	decodes := []struct {
+		hex   string
+		value any
+	}{
+		{"f93c00", 1.0},
+		{"f97bff", 65504.0},
+		{"f9c400", -4.0},
+		{"c11a514b67b0", time.Unix(1363896240, 0)},
+		{"5f42010243030405ff", []byte{1, 2, 3, 4, 5}},
+		{"7f657374726561646d696e67ff", "streaming"},
+		{"9f018202039f0405ffff", []any{int64(1), []any{int64(2), int64(3)}, []any{int64(4), int64(5)}}},
+		{"bf61610161629f0203ffff", map[string]any{"a": int64(1), "b": []any{int64(2), int64(3)}}},
+		{"a201020304", map[any]any{int64(1): int64(2), int64(3): int64(4)}},
+	}
+
+	for _, d := range decodes {
+		var decoded any
+		err := CborCodec{}.Unmarshal(mustHex(t, d.hex), &decoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, d.value, decoded, d.hex)
+	}
+}
+
+func TestBinaryCodecStructs(t *testing.T) {
+	reading := Reading{
+		Sensor: "t-1",
+		Value:  21.5,
+		Raw:    []byte{0xca, 0xfe},
+		At:     time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Skip:   "ignored",
+	}
+
+	for _, codec := range []Codec{MsgpackCodec{}, CborCodec{}} {
+		data, err := codec.Marshal(reading)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var fields map[string]any
+		err = codec.Unmarshal(data, &fields)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Contains(t, fields, "s")
+		assert.Contains(t, fields, "value")
+		assert.NotContains(t, fields, "tags")
+		assert.NotContains(t, fields, "Skip")
+
+		var decoded Reading
+		err = codec.Unmarshal(data, &decoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, reading.Sensor, decoded.Sensor)
+		assert.Equal(t, reading.Value, decoded.Value)
+		assert.Equal(t, reading.Raw, decoded.Raw)
+		assert.True(t, reading.At.Equal(decoded.At))
+		assert.Empty(t, decoded.Skip)
+	}
+}
+
+func TestBinaryCodecRejectsMalformedInput(t *testing.T) {
+	var v any
+	assert.Error(t, MsgpackCodec{}.Unmarshal(mustHex(t, "dd7fffffff"), &v))
+	assert.Error(t, MsgpackCodec{}.Unmarshal(mustHex(t, "c1"), &v))
+	assert.Error(t, MsgpackCodec{}.Unmarshal(mustHex(t, "0101"), &v))
+	assert.Error(t, CborCodec{}.Unmarshal(mustHex(t, "9b00000000ffffffff"), &v))
+	assert.Error(t, CborCodec{}.Unmarshal(mustHex(t, "ff"), &v))
+
+	var small int8
+	assert.Error(t, CborCodec{}.Unmarshal(mustHex(t, "1903e8"), &small))
+}
+
+func TestGetJsonWithMsgpackResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := MsgpackCodec{}.Marshal(Post{Id: 3, Title: "packed"})
+		w.Header().Set("Content-Type", "application/msgpack")
+		_, _ = w.Write(data)
+	}))
+	defer server.Close()
+
+	useClient(t, &Client{})
+	var post Post
+	_, err := GetJson(server.URL, &post)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, Post{Id: 3, Title: "packed"}, post)
+}
@@ -0,0 +1,421 @@
+package ask
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+)
+
+// CborCodec encodes values as CBOR (RFC 8949). Struct fields are named by the
+// cbor tag, falling back to the json tag and then the field name.
+type CborCodec struct{}
+
+// CborTag holds a tagged CBOR data item whose tag is not understood natively.
+type CborTag struct {
+	Number  uint64
+	Content any
+}
+
+const (
+	cborUnsigned = 0 << 5
+	cborNegative = 1 << 5
+	cborBytes    = 2 << 5
+	cborText     = 3 << 5
+	cborArray    = 4 << 5
+	cborMap      = 5 << 5
+	cborTag      = 6 << 5
+	cborSimple   = 7 << 5
+
+	cborIndefinite = 31
+	cborBreak      = 0xff
+)
+
+func (CborCodec) ContentType() string {
+	return "application/cbor"
+}
+
+func (CborCodec) Marshal(v any) ([]byte, error) {
+	enc := &cborEncoder{}
+	err := encodeBinary(enc, "cbor", reflect.ValueOf(v))
+	if err != nil {
+		return nil, err
+	}
+	return enc.buf, nil
+}
+
+func (CborCodec) Unmarshal(data []byte, v any) error {
+	dec := &cborDecoder{data: data}
+	decoded, err := dec.decode(0)
+	if err != nil {
+		return err
+	}
+	if decoded == cborBreakMarker {
+		return fmt.Errorf("ask: unexpected cbor break")
+	}
+	if dec.pos != len(data) {
+		return fmt.Errorf("ask: %d trailing bytes after cbor value", len(data)-dec.pos)
+	}
+	return unmarshalBinary(decoded, "cbor", v)
+}
+
+type cborEncoder struct {
+	buf []byte
+}
+
+func (enc *cborEncoder) writeHeader(major byte, n uint64) {
+	switch {
+	case n < 24:
+		enc.buf = append(enc.buf, major|byte(n))
+	case n <= math.MaxUint8:
+		enc.buf = append(enc.buf, major|24, byte(n))
+	case n <= math.MaxUint16:
+		enc.buf = binary.BigEndian.AppendUint16(append(enc.buf, major|25), uint16(n))
+	case n <= math.MaxUint32:
+		enc.buf = binary.BigEndian.AppendUint32(append(enc.buf, major|26), uint32(n))
+	default:
+		enc.buf = binary.BigEndian.AppendUint64(append(enc.buf, major|27), n)
+	}
+}
+
+func (enc *cborEncoder) encodeNil() {
+	enc.buf = append(enc.buf, 0xf6)
+}
+
+func (enc *cborEncoder) encodeBool(b bool) {
+	if b {
+		enc.buf = append(enc.buf, 0xf5)
+	} else {
+		enc.buf = append(enc.buf, 0xf4)
+	}
+}
+
+func (enc *cborEncoder) encodeInt(n int64) {
+	if n >= 0 {
+		enc.writeHeader(cborUnsigned, uint64(n))
+	} else {
+		enc.writeHeader(cborNegative, uint64(-1-n))
+	}
+}
+
+func (enc *cborEncoder) encodeUint(n uint64) {
+	enc.writeHeader(cborUnsigned, n)
+}
+
+func (enc *cborEncoder) encodeFloat32(f float32) {
+	enc.buf = binary.BigEndian.AppendUint32(append(enc.buf, 0xfa), math.Float32bits(f))
+}
+
+// encodeFloat64 uses single precision whenever it is lossless.
+func (enc *cborEncoder) encodeFloat64(f float64) {
+	if float64(float32(f)) == f || math.IsNaN(f) {
+		enc.encodeFloat32(float32(f))
+		return
+	}
+	enc.buf = binary.BigEndian.AppendUint64(append(enc.buf, 0xfb), math.Float64bits(f))
+}
+
+func (enc *cborEncoder) encodeString(s string) {
+	enc.writeHeader(cborText, uint64(len(s)))
+	enc.buf = append(enc.buf, s...)
+}
+
+func (enc *cborEncoder) encodeBytes(b []byte) {
+	enc.writeHeader(cborBytes, uint64(len(b)))
+	enc.buf = append(enc.buf, b...)
+}
+
+func (enc *cborEncoder) encodeArrayHeader(n int) {
+	enc.writeHeader(cborArray, uint64(n))
+}
+
+func (enc *cborEncoder) encodeMapHeader(n int) {
+	enc.writeHeader(cborMap, uint64(n))
+}
+
+// encodeTime writes a standard date/time string (tag 0).
+func (enc *cborEncoder) encodeTime(t time.Time) {
+	enc.writeHeader(cborTag, 0)
+	enc.encodeString(t.Format(time.RFC3339Nano))
+}
+
+type cborBreakType struct{}
+
+// cborBreakMarker is returned by decode when it reads the break stop code that
+// ends an indefinite-length item.
+var cborBreakMarker = cborBreakType{}
+
+type cborDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (dec *cborDecoder) read(n uint64) ([]byte, error) {
+	if n > uint64(len(dec.data)-dec.pos) {
+		return nil, errUnexpectedEnd
+	}
+	b := dec.data[dec.pos : dec.pos+int(n)]
+	dec.pos += int(n)
+	return b, nil
+}
+
+// readArgument reads the argument that follows an initial byte with the given
+// additional information.
+func (dec *cborDecoder) readArgument(info byte) (uint64, error) {
+	if info < 24 {
+		return uint64(info), nil
+	}
+	if info > 27 {
+		return 0, fmt.Errorf("ask: invalid cbor additional information %d", info)
+	}
+	b, err := dec.read(1 << (info - 24))
+	if err != nil {
+		return 0, err
+	}
+	switch info {
+	case 24:
+		return uint64(b[0]), nil
+	case 25:
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	case 26:
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	default:
+		return binary.BigEndian.Uint64(b), nil
+	}
+}
+
+func (dec *cborDecoder) decode(depth int) (any, error) {
+	if depth > maxDecodeDepth {
+		return nil, fmt.Errorf("ask: cbor value nested deeper than %d", maxDecodeDepth)
+	}
+	b, err := dec.read(1)
+	if err != nil {
+		return nil, err
+	}
+	major := b[0] & 0xe0
+	info := b[0] & 0x1f
+
+	if b[0] == cborBreak {
+		return cborBreakMarker, nil
+	}
+	if info == cborIndefinite {
+		return dec.decodeIndefinite(major, depth)
+	}
+	if major == cborSimple {
+		return dec.decodeSimple(info)
+	}
+
+	n, err := dec.readArgument(info)
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case cborUnsigned:
+		if n > math.MaxInt64 {
+			return n, nil
+		}
+		return int64(n), nil
+	case cborNegative:
+		if n > math.MaxInt64 {
+			return nil, fmt.Errorf("ask: cbor negative integer overflows int64")
+		}
+		return -1 - int64(n), nil
+	case cborBytes:
+		s, err := dec.read(n)
+		return append([]byte(nil), s...), err
+	case cborText:
+		s, err := dec.read(n)
+		return string(s), err
+	case cborArray:
+		if n > uint64(len(dec.data)-dec.pos) {
+			return nil, errUnexpectedEnd
+		}
+		items := make([]any, n)
+		for i := range items {
+			if items[i], err = dec.decodeItem(depth); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	case cborMap:
+		if n > uint64(len(dec.data)-dec.pos) {
+			return nil, errUnexpectedEnd
+		}
+		keys := make([]any, n)
+		values := make([]any, n)
+		for i := range keys {
+			if keys[i], err = dec.decodeItem(depth); err != nil {
+				return nil, err
+			}
+			if values[i], err = dec.decodeItem(depth); err != nil {
+				return nil, err
+			}
+		}
+		return newDecodedMap(keys, values)
+	default:
+		content, err := dec.decodeItem(depth)
+		if err != nil {
+			return nil, err
+		}
+		return decodeCborTag(n, content)
+	}
+}
+
+// decodeItem decodes a nested data item, where a break code is not allowed.
+func (dec *cborDecoder) decodeItem(depth int) (any, error) {
+	item, err := dec.decode(depth + 1)
+	if err != nil {
+		return nil, err
+	}
+	if item == cborBreakMarker {
+		return nil, fmt.Errorf("ask: unexpected cbor break")
+	}
+	return item, nil
+}
+
+func (dec *cborDecoder) decodeIndefinite(major byte, depth int) (any, error) {
+	switch major {
+	case cborBytes, cborText:
+		var chunks []byte
+		for {
+			chunk, err := dec.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			switch c := chunk.(type) {
+			case cborBreakType:
+				if major == cborText {
+					return string(chunks), nil
+				}
+				return chunks, nil
+			case []byte:
+				if major != cborBytes {
+					return nil, fmt.Errorf("ask: invalid cbor text chunk")
+				}
+				chunks = append(chunks, c...)
+			case string:
+				if major != cborText {
+					return nil, fmt.Errorf("ask: invalid cbor byte string chunk")
+				}
+				chunks = append(chunks, c...)
+			default:
+				return nil, fmt.Errorf("ask: invalid cbor string chunk %T", chunk)
+			}
+		}
+	case cborArray:
+		var items []any
+		for {
+			item, err := dec.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			if item == cborBreakMarker {
+				if items == nil {
+					items = []any{}
+				}
+				return items, nil
+			}
+			items = append(items, item)
+		}
+	case cborMap:
+		var keys, values []any
+		for {
+			key, err := dec.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			if key == cborBreakMarker {
+				return newDecodedMap(keys, values)
+			}
+			value, err := dec.decodeItem(depth)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, key)
+			values = append(values, value)
+		}
+	}
+	return nil, fmt.Errorf("ask: invalid indefinite length for cbor major type %d", major>>5)
+}
+
+func (dec *cborDecoder) decodeSimple(info byte) (any, error) {
+	switch info {
+	case 20:
+		return false, nil
+	case 21:
+		return true, nil
+	case 22, 23:
+		return nil, nil
+	case 24:
+		// One-byte simple values carry no data we can represent.
+		_, err := dec.read(1)
+		return nil, err
+	case 25:
+		b, err := dec.read(2)
+		if err != nil {
+			return nil, err
+		}
+		return halfToFloat64(binary.BigEndian.Uint16(b)), nil
+	case 26:
+		b, err := dec.read(4)
+		if err != nil {
+			return nil, err
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), nil
+	case 27:
+		b, err := dec.read(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), nil
+	}
+	if info < 20 {
+		return nil, nil
+	}
+	return nil, fmt.Errorf("ask: invalid cbor simple value %d", info)
+}
+
+func decodeCborTag(number uint64, content any) (any, error) {
+	switch number {
+	case 0:
+		if s, ok := content.(string); ok {
+			return time.Parse(time.RFC3339Nano, s)
+		}
+	case 1:
+		switch epoch := content.(type) {
+		case int64:
+			return time.Unix(epoch, 0), nil
+		case uint64:
+			return time.Unix(int64(epoch), 0), nil
+		case float64:
+			sec, frac := math.Modf(epoch)
+			return time.Unix(int64(sec), int64(frac*1e9)), nil
+		}
+	}
+	return CborTag{Number: number, Content: content}, nil
+}
+
+func halfToFloat64(half uint16) float64 {
+	exp := int(half>>10) & 0x1f
+	mant := float64(half & 0x3ff)
+
+	var value float64
+	switch exp {
+	case 0:
+		value = math.Ldexp(mant, -24)
+	case 31:
+		if mant == 0 {
+			value = math.Inf(1)
+		} else {
+			value = math.NaN()
+		}
+	default:
+		value = math.Ldexp(mant+1024, exp-25)
+	}
+	if half&0x8000 != 0 {
+		return -value
+	}
+	return value
+}
@@ -0,0 +1,334 @@
+package ask
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/csv"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CsvCodec encodes slices of structs, maps or string slices as CSV with a
+// header row. Struct columns are named by the csv tag or the field name.
+type CsvCodec struct{}
+
+func (CsvCodec) ContentType() string {
+	return "text/csv"
+}
+
+func (CsvCodec) Marshal(v any) ([]byte, error) {
+	rows := indirect(reflect.ValueOf(v))
+	if rows.Kind() != reflect.Slice && rows.Kind() != reflect.Array {
+		return nil, fmt.Errorf("ask: csv cannot encode %T", v)
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	elem := rows.Type().Elem()
+	for elem.Kind() == reflect.Pointer {
+		elem = elem.Elem()
+	}
+
+	switch {
+	case elem.Kind() == reflect.Slice && elem.Elem().Kind() == reflect.String:
+		for i := 0; i < rows.Len(); i++ {
+			row := indirect(rows.Index(i))
+			var record []string
+			if row.IsValid() {
+				record = make([]string, row.Len())
+			}
+			for j := range record {
+				record[j] = row.Index(j).String()
+			}
+			if err := writer.Write(record); err != nil {
+				return nil, err
+			}
+		}
+	case elem.Kind() == reflect.Map && elem.Key().Kind() == reflect.String && elem.Elem().Kind() == reflect.String:
+		keys := map[string]bool{}
+		for i := 0; i < rows.Len(); i++ {
+			row := indirect(rows.Index(i))
+			if !row.IsValid() {
+				continue
+			}
+			for _, key := range row.MapKeys() {
+				keys[key.String()] = true
+			}
+		}
+		header := make([]string, 0, len(keys))
+		for key := range keys {
+			header = append(header, key)
+		}
+		sort.Strings(header)
+		if err := writer.Write(header); err != nil {
+			return nil, err
+		}
+		for i := 0; i < rows.Len(); i++ {
+			row := indirect(rows.Index(i))
+			record := make([]string, len(header))
+			for j, key := range header {
+				if !row.IsValid() {
+					break
+				}
+				if value := row.MapIndex(reflect.ValueOf(key).Convert(elem.Key())); value.IsValid() {
+					record[j] = value.String()
+				}
+			}
+			if err := writer.Write(record); err != nil {
+				return nil, err
+			}
+		}
+	case elem.Kind() == reflect.Struct:
+		fields := csvFields(elem)
+		header := make([]string, len(fields))
+		for i, field := range fields {
+			header[i] = field.name
+		}
+		if err := writer.Write(header); err != nil {
+			return nil, err
+		}
+		for i := 0; i < rows.Len(); i++ {
+			row := indirect(rows.Index(i))
+			record := make([]string, len(fields))
+			if row.IsValid() {
+				for j, field := range fields {
+					value, err := formatCsvValue(row.Field(field.index))
+					if err != nil {
+						return nil, err
+					}
+					record[j] = value
+				}
+			}
+			if err := writer.Write(record); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("ask: csv cannot encode %T", v)
+	}
+
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
+
+func (CsvCodec) Unmarshal(data []byte, v any) error {
+	target := reflect.ValueOf(v)
+	if target.Kind() != reflect.Pointer || target.IsNil() {
+		return fmt.Errorf("ask: csv cannot decode into %T", v)
+	}
+	rows := target.Elem()
+	if rows.Kind() == reflect.Interface && rows.NumMethod() == 0 {
+		records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+		if err != nil {
+			return err
+		}
+		rows.Set(reflect.ValueOf(records))
+		return nil
+	}
+	if rows.Kind() != reflect.Slice {
+		return fmt.Errorf("ask: csv cannot decode into %T", v)
+	}
+
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return err
+	}
+
+	elem := rows.Type().Elem()
+	base := elem
+	for base.Kind() == reflect.Pointer {
+		base = base.Elem()
+	}
+	if base.Kind() == reflect.Slice && base.Elem().Kind() == reflect.String {
+		result := reflect.MakeSlice(rows.Type(), 0, len(records))
+		for _, record := range records {
+			row := reflect.MakeSlice(base, len(record), len(record))
+			for i, field := range record {
+				row.Index(i).SetString(field)
+			}
+			result = reflect.Append(result, pointerTo(row, elem))
+		}
+		rows.Set(result)
+		return nil
+	}
+	if len(records) == 0 {
+		rows.Set(reflect.MakeSlice(rows.Type(), 0, 0))
+		return nil
+	}
+
+	header := records[0]
+	result := reflect.MakeSlice(rows.Type(), 0, len(records)-1)
+
+	switch {
+	case base.Kind() == reflect.Map && base.Key().Kind() == reflect.String && base.Elem().Kind() == reflect.String:
+		for _, record := range records[1:] {
+			row := reflect.MakeMapWithSize(base, len(header))
+			for i, column := range header {
+				if i < len(record) {
+					row.SetMapIndex(reflect.ValueOf(column).Convert(base.Key()), reflect.ValueOf(record[i]).Convert(base.Elem()))
+				}
+			}
+			result = reflect.Append(result, pointerTo(row, elem))
+		}
+	case base.Kind() == reflect.Struct:
+		columns := map[string]int{}
+		for _, field := range csvFields(base) {
+			columns[strings.ToLower(field.name)] = field.index
+		}
+		for line, record := range records[1:] {
+			row := reflect.New(base).Elem()
+			for i, column := range header {
+				index, ok := columns[strings.ToLower(strings.TrimSpace(column))]
+				if !ok || i >= len(record) {
+					continue
+				}
+				err := parseCsvValue(row.Field(index), record[i])
+				if err != nil {
+					return fmt.Errorf("ask: csv line %d, column %q: %w", line+2, column, err)
+				}
+			}
+			result = reflect.Append(result, pointerTo(row, elem))
+		}
+	default:
+		return fmt.Errorf("ask: csv cannot decode into %T", v)
+	}
+
+	rows.Set(result)
+	return nil
+}
+
+type csvField struct {
+	name  string
+	index int
+}
+
+func csvFields(t reflect.Type) []csvField {
+	var fields []csvField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := field.Name
+		if tag, ok := field.Tag.Lookup("csv"); ok {
+			tag, _, _ = strings.Cut(tag, ",")
+			if tag == "-" {
+				continue
+			}
+			if tag != "" {
+				name = tag
+			}
+		}
+		fields = append(fields, csvField{name: name, index: i})
+	}
+	return fields
+}
+
+// indirect follows every pointer and interface of value. It returns the zero
+// Value on nil.
+func indirect(value reflect.Value) reflect.Value {
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return reflect.Value{}
+		}
+		value = value.Elem()
+	}
+	return value
+}
+
+func pointerTo(value reflect.Value, t reflect.Type) reflect.Value {
+	for value.Type() != t {
+		ptr := reflect.New(value.Type())
+		ptr.Elem().Set(value)
+		value = ptr
+	}
+	return value
+}
+
+func formatCsvValue(value reflect.Value) (string, error) {
+	if value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return "", nil
+		}
+		value = value.Elem()
+	}
+	if marshaler, ok := value.Interface().(encoding.TextMarshaler); ok {
+		text, err := marshaler.MarshalText()
+		return string(text), err
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		return value.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(value.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(value.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(value.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(value.Float(), 'f', -1, value.Type().Bits()), nil
+	}
+	return fmt.Sprint(value.Interface()), nil
+}
+
+func parseCsvValue(value reflect.Value, text string) error {
+	if value.Kind() == reflect.Pointer {
+		if text == "" {
+			return nil
+		}
+		value.Set(reflect.New(value.Type().Elem()))
+		value = value.Elem()
+	}
+	if unmarshaler, ok := value.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return unmarshaler.UnmarshalText([]byte(text))
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(text)
+	case reflect.Bool:
+		if text == "" {
+			return nil
+		}
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return err
+		}
+		value.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if text == "" {
+			return nil
+		}
+		n, err := strconv.ParseInt(text, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if text == "" {
+			return nil
+		}
+		n, err := strconv.ParseUint(text, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		if text == "" {
+			return nil
+		}
+		f, err := strconv.ParseFloat(text, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", value.Type())
+	}
+	return nil
+}
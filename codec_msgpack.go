@@ -0,0 +1,363 @@
+package ask
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+)
+
+// MsgpackCodec encodes values as MessagePack. Struct fields are named by the
+// msgpack tag, falling back to the json tag and then the field name.
+type MsgpackCodec struct{}
+
+// MsgpackExtension holds a MessagePack extension value other than timestamps.
+type MsgpackExtension struct {
+	Type int8
+	Data []byte
+}
+
+func (MsgpackCodec) ContentType() string {
+	return "application/msgpack"
+}
+
+func (MsgpackCodec) Marshal(v any) ([]byte, error) {
+	enc := &msgpackEncoder{}
+	err := encodeBinary(enc, "msgpack", reflect.ValueOf(v))
+	if err != nil {
+		return nil, err
+	}
+	return enc.buf, nil
+}
+
+func (MsgpackCodec) Unmarshal(data []byte, v any) error {
+	dec := &msgpackDecoder{data: data}
+	decoded, err := dec.decode(0)
+	if err != nil {
+		return err
+	}
+	if dec.pos != len(data) {
+		return fmt.Errorf("ask: %d trailing bytes after msgpack value", len(data)-dec.pos)
+	}
+	return unmarshalBinary(decoded, "msgpack", v)
+}
+
+type msgpackEncoder struct {
+	buf []byte
+}
+
+func (enc *msgpackEncoder) encodeNil() {
+	enc.buf = append(enc.buf, 0xc0)
+}
+
+func (enc *msgpackEncoder) encodeBool(b bool) {
+	if b {
+		enc.buf = append(enc.buf, 0xc3)
+	} else {
+		enc.buf = append(enc.buf, 0xc2)
+	}
+}
+
+func (enc *msgpackEncoder) encodeInt(n int64) {
+	switch {
+	case n >= 0:
+		enc.encodeUint(uint64(n))
+	case n >= -32:
+		enc.buf = append(enc.buf, byte(n))
+	case n >= math.MinInt8:
+		enc.buf = append(enc.buf, 0xd0, byte(n))
+	case n >= math.MinInt16:
+		enc.buf = binary.BigEndian.AppendUint16(append(enc.buf, 0xd1), uint16(n))
+	case n >= math.MinInt32:
+		enc.buf = binary.BigEndian.AppendUint32(append(enc.buf, 0xd2), uint32(n))
+	default:
+		enc.buf = binary.BigEndian.AppendUint64(append(enc.buf, 0xd3), uint64(n))
+	}
+}
+
+func (enc *msgpackEncoder) encodeUint(n uint64) {
+	switch {
+	case n <= 0x7f:
+		enc.buf = append(enc.buf, byte(n))
+	case n <= math.MaxUint8:
+		enc.buf = append(enc.buf, 0xcc, byte(n))
+	case n <= math.MaxUint16:
+		enc.buf = binary.BigEndian.AppendUint16(append(enc.buf, 0xcd), uint16(n))
+	case n <= math.MaxUint32:
+		enc.buf = binary.BigEndian.AppendUint32(append(enc.buf, 0xce), uint32(n))
+	default:
+		enc.buf = binary.BigEndian.AppendUint64(append(enc.buf, 0xcf), n)
+	}
+}
+
+func (enc *msgpackEncoder) encodeFloat32(f float32) {
+	enc.buf = binary.BigEndian.AppendUint32(append(enc.buf, 0xca), math.Float32bits(f))
+}
+
+func (enc *msgpackEncoder) encodeFloat64(f float64) {
+	enc.buf = binary.BigEndian.AppendUint64(append(enc.buf, 0xcb), math.Float64bits(f))
+}
+
+func (enc *msgpackEncoder) encodeString(s string) {
+	n := len(s)
+	switch {
+	case n < 32:
+		enc.buf = append(enc.buf, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		enc.buf = append(enc.buf, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		enc.buf = binary.BigEndian.AppendUint16(append(enc.buf, 0xda), uint16(n))
+	default:
+		enc.buf = binary.BigEndian.AppendUint32(append(enc.buf, 0xdb), uint32(n))
+	}
+	enc.buf = append(enc.buf, s...)
+}
+
+func (enc *msgpackEncoder) encodeBytes(b []byte) {
+	n := len(b)
+	switch {
+	case n <= math.MaxUint8:
+		enc.buf = append(enc.buf, 0xc4, byte(n))
+	case n <= math.MaxUint16:
+		enc.buf = binary.BigEndian.AppendUint16(append(enc.buf, 0xc5), uint16(n))
+	default:
+		enc.buf = binary.BigEndian.AppendUint32(append(enc.buf, 0xc6), uint32(n))
+	}
+	enc.buf = append(enc.buf, b...)
+}
+
+func (enc *msgpackEncoder) encodeArrayHeader(n int) {
+	switch {
+	case n < 16:
+		enc.buf = append(enc.buf, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		enc.buf = binary.BigEndian.AppendUint16(append(enc.buf, 0xdc), uint16(n))
+	default:
+		enc.buf = binary.BigEndian.AppendUint32(append(enc.buf, 0xdd), uint32(n))
+	}
+}
+
+func (enc *msgpackEncoder) encodeMapHeader(n int) {
+	switch {
+	case n < 16:
+		enc.buf = append(enc.buf, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		enc.buf = binary.BigEndian.AppendUint16(append(enc.buf, 0xde), uint16(n))
+	default:
+		enc.buf = binary.BigEndian.AppendUint32(append(enc.buf, 0xdf), uint32(n))
+	}
+}
+
+// encodeTime writes the timestamp extension (type -1) in its smallest form.
+func (enc *msgpackEncoder) encodeTime(t time.Time) {
+	sec := t.Unix()
+	nsec := int64(t.Nanosecond())
+	switch {
+	case sec>>34 == 0 && nsec == 0 && sec <= math.MaxUint32:
+		enc.buf = binary.BigEndian.AppendUint32(append(enc.buf, 0xd6, 0xff), uint32(sec))
+	case sec>>34 == 0:
+		enc.buf = binary.BigEndian.AppendUint64(append(enc.buf, 0xd7, 0xff), uint64(nsec)<<34|uint64(sec))
+	default:
+		enc.buf = append(enc.buf, 0xc7, 12, 0xff)
+		enc.buf = binary.BigEndian.AppendUint32(enc.buf, uint32(nsec))
+		enc.buf = binary.BigEndian.AppendUint64(enc.buf, uint64(sec))
+	}
+}
+
+type msgpackDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (dec *msgpackDecoder) read(n int) ([]byte, error) {
+	if n < 0 || len(dec.data)-dec.pos < n {
+		return nil, errUnexpectedEnd
+	}
+	b := dec.data[dec.pos : dec.pos+n]
+	dec.pos += n
+	return b, nil
+}
+
+func (dec *msgpackDecoder) readUint(size int) (uint64, error) {
+	b, err := dec.read(size)
+	if err != nil {
+		return 0, err
+	}
+	switch size {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	default:
+		return binary.BigEndian.Uint64(b), nil
+	}
+}
+
+func (dec *msgpackDecoder) readLength(size int) (int, error) {
+	n, err := dec.readUint(size)
+	if err != nil {
+		return 0, err
+	}
+	if n > uint64(len(dec.data)-dec.pos) {
+		return 0, errUnexpectedEnd
+	}
+	return int(n), nil
+}
+
+func (dec *msgpackDecoder) decode(depth int) (any, error) {
+	if depth > maxDecodeDepth {
+		return nil, fmt.Errorf("ask: msgpack value nested deeper than %d", maxDecodeDepth)
+	}
+	b, err := dec.read(1)
+	if err != nil {
+		return nil, err
+	}
+	code := b[0]
+
+	switch {
+	case code <= 0x7f:
+		return int64(code), nil
+	case code >= 0xe0:
+		return int64(int8(code)), nil
+	case code&0xf0 == 0x80:
+		return dec.decodeMap(int(code&0x0f), depth)
+	case code&0xf0 == 0x90:
+		return dec.decodeArray(int(code&0x0f), depth)
+	case code&0xe0 == 0xa0:
+		s, err := dec.read(int(code & 0x1f))
+		return string(s), err
+	}
+
+	switch code {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := dec.readLength(1 << (code - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		b, err := dec.read(n)
+		return append([]byte(nil), b...), err
+	case 0xc7, 0xc8, 0xc9:
+		n, err := dec.readLength(1 << (code - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return dec.decodeExtension(n)
+	case 0xca:
+		n, err := dec.readUint(4)
+		return float64(math.Float32frombits(uint32(n))), err
+	case 0xcb:
+		n, err := dec.readUint(8)
+		return math.Float64frombits(n), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		n, err := dec.readUint(1 << (code - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		if n > math.MaxInt64 {
+			return n, nil
+		}
+		return int64(n), nil
+	case 0xd0:
+		n, err := dec.readUint(1)
+		return int64(int8(n)), err
+	case 0xd1:
+		n, err := dec.readUint(2)
+		return int64(int16(n)), err
+	case 0xd2:
+		n, err := dec.readUint(4)
+		return int64(int32(n)), err
+	case 0xd3:
+		n, err := dec.readUint(8)
+		return int64(n), err
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return dec.decodeExtension(1 << (code - 0xd4))
+	case 0xd9, 0xda, 0xdb:
+		n, err := dec.readLength(1 << (code - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		s, err := dec.read(n)
+		return string(s), err
+	case 0xdc, 0xdd:
+		n, err := dec.readLength(2 << (code - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return dec.decodeArray(n, depth)
+	case 0xde, 0xdf:
+		n, err := dec.readLength(2 << (code - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return dec.decodeMap(n, depth)
+	}
+	return nil, fmt.Errorf("ask: invalid msgpack code 0x%02x", code)
+}
+
+func (dec *msgpackDecoder) decodeArray(n int, depth int) (any, error) {
+	items := make([]any, n)
+	for i := range items {
+		item, err := dec.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		items[i] = item
+	}
+	return items, nil
+}
+
+func (dec *msgpackDecoder) decodeMap(n int, depth int) (any, error) {
+	keys := make([]any, n)
+	values := make([]any, n)
+	for i := 0; i < n; i++ {
+		key, err := dec.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		value, err := dec.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = key
+		values[i] = value
+	}
+	return newDecodedMap(keys, values)
+}
+
+func (dec *msgpackDecoder) decodeExtension(n int) (any, error) {
+	header, err := dec.read(1)
+	if err != nil {
+		return nil, err
+	}
+	data, err := dec.read(n)
+	if err != nil {
+		return nil, err
+	}
+
+	extType := int8(header[0])
+	if extType != -1 {
+		return MsgpackExtension{Type: extType, Data: append([]byte(nil), data...)}, nil
+	}
+
+	switch n {
+	case 4:
+		return time.Unix(int64(binary.BigEndian.Uint32(data)), 0), nil
+	case 8:
+		v := binary.BigEndian.Uint64(data)
+		return time.Unix(int64(v&0x3ffffffff), int64(v>>34)), nil
+	case 12:
+		nsec := binary.BigEndian.Uint32(data[:4])
+		sec := int64(binary.BigEndian.Uint64(data[4:]))
+		return time.Unix(sec, int64(nsec)), nil
+	}
+	return nil, fmt.Errorf("ask: invalid msgpack timestamp length %d", n)
+}
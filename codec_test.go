@@ -0,0 +1,157 @@
+package ask
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type Book struct {
+	Title  string  `xml:"title" yaml:"title" csv:"title"`
+	Pages  int     `xml:"pages" yaml:"pages" csv:"pages"`
+	Price  float64 `xml:"price" yaml:"price" csv:"price"`
+	Hidden string  `xml:"-" yaml:"-" csv:"-"`
+}
+
+func echoServer(contentType string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("X-Request-Content-Type", r.Header.Get("Content-Type"))
+		_, _ = io.Copy(w, r.Body)
+	}))
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	cases := []struct {
+		contentType string
+		echoed      string
+	}{
+		{"application/xml", "text/xml; charset=utf-8"},
+		{"application/yaml", "application/x-yaml"},
+		{"application/json", "application/problem+json"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.contentType, func(t *testing.T) {
+			server := echoServer(c.echoed)
+			defer server.Close()
+
+			request := NewRequest(http.MethodPost, server.URL)
+			_, err := request.WithPayload(c.contentType, Book{Title: "Dune", Pages: 412, Price: 9.5})
+			if err != nil {
+				t.Fatal(err)
+			}
+			response, err := request.Accept(c.contentType).Send()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var book Book
+			err = response.Decode(&book)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, Book{Title: "Dune", Pages: 412, Price: 9.5}, book)
+		})
+	}
+}
+
+func TestCsvCodec(t *testing.T) {
+	books := []Book{{Title: "Dune", Pages: 412, Price: 9.5, Hidden: "x"}, {Title: "Emma", Pages: 474}}
+
+	data, err := CsvCodec{}.Marshal(books)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "title,pages,price\nDune,412,9.5\nEmma,474,0\n", string(data))
+
+	var decoded []*Book
+	err = CsvCodec{}.Unmarshal(data, &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []*Book{{Title: "Dune", Pages: 412, Price: 9.5}, {Title: "Emma", Pages: 474}}, decoded)
+
+	var rows []map[string]string
+	err = CsvCodec{}.Unmarshal(data, &rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "474", rows[1]["pages"])
+}
+
+type csvRow []string
+
+type csvRecord map[string]string
+
+func TestCsvCodecRowTypes(t *testing.T) {
+	rows := []csvRow{{"title", "pages"}, {"Dune", "412"}}
+	data, err := CsvCodec{}.Marshal(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "title,pages\nDune,412\n", string(data))
+
+	first, second := &rows[0], &rows[1]
+	pointers := []**csvRow{&first, &second}
+	data, err = CsvCodec{}.Marshal(&pointers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "title,pages\nDune,412\n", string(data))
+
+	var decoded []csvRow
+	assert.NoError(t, CsvCodec{}.Unmarshal(data, &decoded))
+	assert.Equal(t, rows, decoded)
+
+	var decodedPointers []*csvRow
+	assert.NoError(t, CsvCodec{}.Unmarshal(data, &decodedPointers))
+	assert.Equal(t, []*csvRow{&rows[0], &rows[1]}, decodedPointers)
+
+	var records []csvRecord
+	assert.NoError(t, CsvCodec{}.Unmarshal(data, &records))
+	assert.Equal(t, []csvRecord{{"title": "Dune", "pages": "412"}}, records)
+	data, err = CsvCodec{}.Marshal(records)
+	assert.NoError(t, err)
+	assert.Equal(t, "pages,title\n412,Dune\n", string(data))
+
+	_, err = CsvCodec{}.Marshal([]int{1})
+	assert.ErrorContains(t, err, "csv cannot encode")
+	_, err = CsvCodec{}.Marshal([]any{[]string{"a"}})
+	assert.ErrorContains(t, err, "csv cannot encode")
+	var ints []int
+	assert.ErrorContains(t, CsvCodec{}.Unmarshal(data, &ints), "csv cannot decode")
+}
+
+type upperCodec struct {
+	JsonCodec
+}
+
+func (upperCodec) ContentType() string {
+	return "application/vnd.upper"
+}
+
+func TestRegisterCodec(t *testing.T) {
+	server := echoServer("application/vnd.upper")
+	defer server.Close()
+
+	c := NewClient(context.Background())
+	c.RegisterCodec(upperCodec{})
+	c.SetDefaultCodec(YamlCodec{})
+
+	assert.Equal(t, upperCodec{}, c.codec("application/vnd.upper; v=2"))
+	assert.Equal(t, YamlCodec{}, c.codec("application/octet-stream"))
+	assert.Equal(t, CsvCodec{}, c.codec("text/csv; header=present"))
+
+	useClient(t, c)
+	var post Post
+	_, err := PostJson(server.URL, []byte(`{"title":"codec"}`), &post)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "codec", post.Title)
+}
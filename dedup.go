@@ -0,0 +1,165 @@
+package ask
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+)
+
+// dedupGroup collapses concurrent identical requests into a single call.
+type dedupGroup struct {
+	headers []string
+
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+type flight struct {
+	done     chan struct{}
+	response *http.Response
+	body     []byte
+	err      error
+	trace    *timingTrace
+
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// SetDeduplication collapses concurrent GET and HEAD requests for the same URL
+// into one network call, whose response is copied to every caller. Requests
+// only share a call when the given headers also have the same values. The
+// shared call sends neither the trace context nor the request id of a caller.
+// Streams, and requests overriding the hedging, redirect or size limit of the
+// client, are never shared.
+func (client *Client) SetDeduplication(flag bool, headers ...string) Client {
+	if flag {
+		client.dedup = &dedupGroup{headers: headers, flights: map[string]*flight{}}
+	} else {
+		client.dedup = nil
+	}
+	return *client
+}
+
+func (group *dedupGroup) key(req *http.Request) (string, bool) {
+	if (req.Method != http.MethodGet && req.Method != http.MethodHead) || req.Body != nil {
+		return "", false
+	}
+
+	var key strings.Builder
+	key.WriteString(req.Method)
+	key.WriteByte(' ')
+	key.WriteString(req.URL.String())
+	for _, header := range group.headers {
+		key.WriteByte('\n')
+		key.WriteString(http.CanonicalHeaderKey(header))
+		key.WriteByte(':')
+		key.WriteString(strings.Join(req.Header.Values(header), ","))
+	}
+	return key.String(), true
+}
+
+// do joins the call in flight for req, or starts one. Each caller waits with
+// its own context; the shared call is cancelled once every caller gave up.
+//
+// The shared call belongs to no caller in particular: it carries no trace
+// context nor request id. Its timings are copied to the trace of every caller.
+func (group *dedupGroup) do(req *http.Request, trace *timingTrace, limit int64, send func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	key, ok := group.key(req)
+	if !ok {
+		return send(req)
+	}
+
+	group.mu.Lock()
+	f, joined := group.flights[key]
+	if !joined {
+		f = &flight{done: make(chan struct{}), trace: newTimingTrace()}
+		ctx, cancel := context.WithCancel(sharedContext{context.WithoutCancel(req.Context())})
+		f.cancel = cancel
+		ctx = httptrace.WithClientTrace(ctx, f.trace.clientTrace())
+		group.flights[key] = f
+		go group.run(key, f, req.WithContext(ctx), limit, send)
+	}
+	f.waiters++
+	group.mu.Unlock()
+
+	select {
+	case <-f.done:
+		if f.err != nil {
+			return nil, f.err
+		}
+		trace.copyFrom(f.trace)
+		response := *f.response
+		response.Header = f.response.Header.Clone()
+		response.Body = io.NopCloser(bytes.NewReader(f.body))
+		if response.Header.Get("Content-Encoding") != "" {
+			// The body was decoded to apply the size limit.
+			response.Header.Del("Content-Encoding")
+			response.Header.Del("Content-Length")
+			response.ContentLength = int64(len(f.body))
+			response.Uncompressed = true
+		}
+		if response.Request == nil || response.Request.Response == nil {
+			// Without redirects, the response is to the request of the caller.
+			response.Request = req
+		}
+		return &response, nil
+	case <-req.Context().Done():
+		group.mu.Lock()
+		f.waiters--
+		if f.waiters == 0 {
+			f.cancel()
+			if group.flights[key] == f {
+				delete(group.flights, key)
+			}
+		}
+		group.mu.Unlock()
+		return nil, req.Context().Err()
+	}
+}
+
+func (group *dedupGroup) run(key string, f *flight, req *http.Request, limit int64, send func(*http.Request) (*http.Response, error)) {
+	defer f.cancel()
+
+	response, err := send(req)
+	if err == nil && response.Body != nil {
+		var body io.ReadCloser
+		body, err = decodedBody(response, limit)
+		if err == nil {
+			f.body, err = io.ReadAll(body)
+			body.Close()
+		} else {
+			response.Body.Close()
+		}
+	}
+	f.response = response
+	f.err = err
+
+	group.mu.Lock()
+	if group.flights[key] == f {
+		delete(group.flights, key)
+	}
+	group.mu.Unlock()
+	close(f.done)
+}
+
+// sharedContext hides the values that belong to the caller starting a shared
+// call: its trace context, request id and httptrace hooks.
+type sharedContext struct {
+	context.Context
+}
+
+func (ctx sharedContext) Value(key any) any {
+	switch key.(type) {
+	case traceKey, requestIdKey:
+		return nil
+	}
+	value := ctx.Context.Value(key)
+	if _, ok := value.(*httptrace.ClientTrace); ok {
+		return nil
+	}
+	return value
+}
@@ -0,0 +1,274 @@
+package ask
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func waitForWaiters(t *testing.T, group *dedupGroup, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		group.mu.Lock()
+		waiters := 0
+		for _, f := range group.flights {
+			waiters += f.waiters
+		}
+		group.mu.Unlock()
+		if waiters == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("expected %d waiters", n)
+}
+
+func TestDeduplicationCollapsesRequests(t *testing.T) {
+	var hits atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":1,"title":"shared"}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(context.Background())
+	c.SetDeduplication(true, "Authorization")
+	useClient(t, c)
+
+	var wg sync.WaitGroup
+	posts := make([]Post, 20)
+	for i := range posts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := GetJson(server.URL+"/posts/1", &posts[i])
+			assert.NoError(t, err)
+		}(i)
+	}
+
+	waitForWaiters(t, c.dedup, 20)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), hits.Load())
+	for _, post := range posts {
+		assert.Equal(t, "shared", post.Title)
+	}
+
+	request := NewRequest(http.MethodGet, server.URL+"/posts/1")
+	request.setClient(c)
+	request.Header.Set("Authorization", "other")
+	_, err := request.Send()
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), hits.Load())
+}
+
+func TestDeduplicationIndependentCancellation(t *testing.T) {
+	upstreamCancelled := make(chan struct{})
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+			_, _ = w.Write([]byte(`{"title":"late"}`))
+		case <-r.Context().Done():
+			close(upstreamCancelled)
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	c := NewClient(context.Background())
+	c.SetDeduplication(true)
+
+	send := func(ctx context.Context) error {
+		request := NewRequest(http.MethodGet, server.URL)
+		request.setClient(c)
+		_, err := request.WithContext(ctx).Send()
+		return err
+	}
+
+	first, cancelFirst := context.WithCancel(context.Background())
+	second, cancelSecond := context.WithCancel(context.Background())
+	errs := make(chan error, 2)
+	go func() { errs <- send(first) }()
+	go func() { errs <- send(second) }()
+	waitForWaiters(t, c.dedup, 2)
+
+	cancelFirst()
+	assert.True(t, errors.Is(<-errs, context.Canceled))
+	select {
+	case <-upstreamCancelled:
+		t.Fatal("shared request cancelled while a caller is still waiting")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	cancelSecond()
+	assert.True(t, errors.Is(<-errs, context.Canceled))
+	select {
+	case <-upstreamCancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("shared request not cancelled")
+	}
+}
+
+func TestDeduplicationSharedCallContext(t *testing.T) {
+	release := make(chan struct{})
+	headers := make(chan http.Header, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header.Clone()
+		<-release
+		_, _ = w.Write([]byte("shared"))
+	}))
+	defer server.Close()
+
+	c := NewClient(context.Background())
+	c.SetDeduplication(true)
+
+	trace := TraceContext{TraceId: "4bf92f3577b34da6a3ce929d0e0e4736", SpanId: "00f067aa0ba902b7", Sampled: true}
+	ctx := ContextWithRequestId(ContextWithTrace(context.Background(), trace), "first")
+	responses := make([]*Response, 2)
+	var wg sync.WaitGroup
+	for i, ctx := range []context.Context{ctx, context.Background()} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			request := NewRequest(http.MethodGet, server.URL)
+			request.setClient(c)
+			response, err := request.WithContext(ctx).Send()
+			assert.NoError(t, err)
+			responses[i] = response
+		}()
+	}
+	waitForWaiters(t, c.dedup, 2)
+	close(release)
+	wg.Wait()
+
+	header := <-headers
+	assert.Empty(t, header.Get("traceparent"))
+	assert.Empty(t, header.Get(DefaultRequestIdHeader))
+	for _, response := range responses {
+		assert.Equal(t, "shared", response.Text())
+		assert.NotZero(t, response.Timings.FirstByte)
+		assert.NotZero(t, response.Timings.Total)
+	}
+}
+
+func TestDeduplicationLimitsDecodedBody(t *testing.T) {
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	_, _ = writer.Write(bytes.Repeat([]byte(" "), 4096))
+	_ = writer.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		_, _ = w.Write(compressed.Bytes())
+	}))
+	defer server.Close()
+
+	c := NewClient(context.Background())
+	c.SetDeduplication(true)
+	c.SetMaxResponseSize(1024)
+
+	request := NewRequest(http.MethodGet, server.URL)
+	request.setClient(c)
+	request.Header.Set("Accept-Encoding", "gzip")
+	_, err := request.Send()
+	assert.ErrorIs(t, err, ErrBodyTooLarge)
+	assert.Less(t, compressed.Len(), 1024)
+
+	c.SetMaxResponseSize(8192)
+	request = NewRequest(http.MethodGet, server.URL)
+	request.setClient(c)
+	request.Header.Set("Accept-Encoding", "gzip")
+	response, err := request.Send()
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Repeat(" ", 4096), response.Text())
+	assert.Empty(t, response.Header.Get("Content-Encoding"))
+}
+
+func TestDeduplicationSkipsStreams(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := 1; ; i++ {
+			if _, err := fmt.Fprintf(w, "data: {\"id\":%d}\n\n", i); err != nil {
+				return
+			}
+			w.(http.Flusher).Flush()
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(5 * time.Millisecond):
+			}
+		}
+	}))
+	defer server.Close()
+
+	c := NewClient(context.Background())
+	c.SetDeduplication(true)
+	c.SetMaxResponseSize(16)
+	useClient(t, c)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var ids []int
+	for post, err := range SubscribeJson[Post](ctx, server.URL) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, post.Id)
+		if len(ids) == 3 {
+			break
+		}
+	}
+	assert.Equal(t, []int{1, 2, 3}, ids)
+}
+
+func TestDeduplicationSkipsRequestOverrides(t *testing.T) {
+	var hits atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		<-release
+		_, _ = w.Write([]byte(strings.Repeat("a", 64)))
+	}))
+	defer server.Close()
+
+	c := NewClient(context.Background())
+	c.SetDeduplication(true)
+
+	errs := make(chan error, 2)
+	go func() {
+		_, err := c.NewRequest(http.MethodGet, server.URL).Send()
+		errs <- err
+	}()
+	waitForWaiters(t, c.dedup, 1)
+	go func() {
+		_, err := c.NewRequest(http.MethodGet, server.URL).WithMaxResponseSize(16).Send()
+		errs <- err
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for hits.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+
+	results := []error{<-errs, <-errs}
+	assert.Equal(t, int32(2), hits.Load())
+	assert.Contains(t, results, nil)
+	assert.True(t, errors.Is(results[0], ErrBodyTooLarge) || errors.Is(results[1], ErrBodyTooLarge))
+}
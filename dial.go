@@ -0,0 +1,119 @@
+package ask
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// DialFunc opens the connections of a client, like net.Dialer.DialContext.
+type DialFunc func(ctx context.Context, network string, address string) (net.Conn, error)
+
+// WithDialContext replaces how connections are opened, e.g. to go through a
+// tunnel or a custom socket.
+func WithDialContext(dial DialFunc) ClientOption {
+	return func(config *transportConfig) {
+		config.dial = dial
+	}
+}
+
+// WithUnixSocket sends every request over the Unix domain socket at path,
+// whatever the URL host. The Host header still comes from the URL.
+func WithUnixSocket(path string) ClientOption {
+	return func(config *transportConfig) {
+		config.dial = func(ctx context.Context, _ string, _ string) (net.Conn, error) {
+			return config.dialer.DialContext(ctx, "unix", path)
+		}
+	}
+}
+
+func (config *transportConfig) dialContext() DialFunc {
+	dial := DialFunc(config.dialer.DialContext)
+	if config.dial != nil {
+		dial = config.dial
+		if config.guard != nil {
+			dial = config.guard.dial(dial)
+		}
+	}
+	if config.resolve != nil {
+		dial = config.resolve.dial(dial)
+	}
+	return dial
+}
+
+// dial checks the peer of connections opened by a custom dialer, which the
+// guard cannot inspect before connecting. Nothing has been sent yet.
+func (guard *ssrfGuard) dial(dial DialFunc) DialFunc {
+	return func(ctx context.Context, network string, address string) (net.Conn, error) {
+		conn, err := dial(ctx, network, address)
+		if err != nil {
+			return nil, err
+		}
+		host, _, _ := net.SplitHostPort(address)
+		tcpAddr, ok := conn.RemoteAddr().(*net.TCPAddr)
+		if !ok {
+			conn.Close()
+			return nil, &SsrfError{Host: host, Reason: "connection is not TCP"}
+		}
+		addr, _ := netip.AddrFromSlice(tcpAddr.IP)
+		if reason := guard.checkAddr(addr); reason != "" {
+			conn.Close()
+			return nil, &SsrfError{Host: host, Address: addr.Unmap().String(), Reason: reason}
+		}
+		return conn, nil
+	}
+}
+
+// unixSocketClient returns a copy of httpClient whose transport dials the
+// socket at path. Clients that do not dial, like mocks, are returned as is;
+// those whose transport cannot be made to dial the socket fail every request.
+func unixSocketClient(httpClient HttpClient, path string) HttpClient {
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+	client, ok := httpClient.(*http.Client)
+	if !ok {
+		return httpClient
+	}
+	transport, err := unixSocketTransport(client.Transport, path)
+	if err != nil {
+		return failingClient{err: err}
+	}
+
+	copied := *client
+	copied.Transport = transport
+	return &copied
+}
+
+func unixSocketTransport(roundTripper http.RoundTripper, path string) (http.RoundTripper, error) {
+	switch transport := roundTripper.(type) {
+	case nil:
+		return unixSocketTransport(http.DefaultTransport, path)
+	case *guardTransport:
+		inner, err := unixSocketTransport(transport.inner, path)
+		if err != nil {
+			return nil, err
+		}
+		return &guardTransport{guard: transport.guard, inner: inner}, nil
+	case *http.Transport:
+		transport = transport.Clone()
+		transport.Proxy = nil
+		dial := transport.DialContext
+		if dial == nil {
+			dial = (&net.Dialer{}).DialContext
+		}
+		transport.DialContext = func(ctx context.Context, _ string, _ string) (net.Conn, error) {
+			return dial(ctx, "unix", path)
+		}
+		return transport, nil
+	}
+	return nil, fmt.Errorf("ask: cannot dial unix socket %s with transport %T", path, roundTripper)
+}
+
+// unixSocketPath returns the socket path of a "unix:///path/to.sock" URL.
+func unixSocketPath(baseUrl string) (string, bool) {
+	return strings.CutPrefix(baseUrl, "unix://")
+}
@@ -0,0 +1,154 @@
+package ask
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func unixServer(t *testing.T) string {
+	dir, err := os.MkdirTemp("", "ask")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "api.sock")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"title":"` + r.Host + r.URL.Path + `"}`))
+	})}
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+	return path
+}
+
+func TestUnixSocketBaseUrl(t *testing.T) {
+	socket := unixServer(t)
+
+	c := NewClient(context.Background())
+	c.SetBaseUrl("unix://" + socket)
+	useClient(t, c)
+
+	var post Post
+	_, err := GetJson("/containers/json", &post)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "localhost/containers/json", post.Title)
+}
+
+func TestUnixSocketOption(t *testing.T) {
+	socket := unixServer(t)
+
+	c := NewClient(context.Background(), WithUnixSocket(socket))
+	var post Post
+	_, err := c.NewRequest(http.MethodGet, "http://docker/version").SendInto(&post)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "docker/version", post.Title)
+}
+
+func TestDialContextHook(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	var dialed atomic.Int32
+	var dialedAddress string
+	dial := func(ctx context.Context, network string, address string) (net.Conn, error) {
+		dialed.Add(1)
+		dialedAddress = address
+		return (&net.Dialer{}).DialContext(ctx, network, server.Listener.Addr().String())
+	}
+
+	c := NewClient(context.Background(), WithDialContext(dial))
+	_, err := c.NewRequest(http.MethodGet, "http://service.test:8080/").Send()
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), dialed.Load())
+	assert.Equal(t, "service.test:8080", dialedAddress)
+
+	c = NewClient(context.Background(), WithDialContext(dial), WithSsrfGuard(SsrfPolicy{}))
+	_, err = c.NewRequest(http.MethodGet, "http://service.test:8080/").Send()
+	assert.ErrorIs(t, err, ErrSsrfBlocked)
+}
+
+func TestUnixSocketBaseUrlReplaced(t *testing.T) {
+	socket := unixServer(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"title":"tcp"}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(context.Background())
+	c.SetBaseUrl("unix://" + socket)
+	c.SetBaseUrl(server.URL)
+
+	var post Post
+	_, err := c.NewRequest(http.MethodGet, "/version").SendInto(&post)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "tcp", post.Title)
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestUnixSocketUnsupportedTransport(t *testing.T) {
+	var sent atomic.Int32
+	c := NewClient(context.Background())
+	c.SetHttpClient(&http.Client{Transport: roundTripperFunc(func(*http.Request) (*http.Response, error) {
+		sent.Add(1)
+		return nil, nil
+	})})
+	c.SetBaseUrl("unix:///var/run/api.sock")
+
+	_, err := c.NewRequest(http.MethodGet, "/version").Send()
+	assert.ErrorContains(t, err, "cannot dial unix socket")
+	assert.Zero(t, sent.Load())
+}
+
+func TestUnixSocketWithSsrfGuard(t *testing.T) {
+	socket := unixServer(t)
+
+	c := NewClient(context.Background(), WithSsrfGuard(SsrfPolicy{}))
+	c.SetBaseUrl("unix://" + socket)
+	_, err := c.NewRequest(http.MethodGet, "/version").Send()
+	// The guard applies to the socket, instead of being bypassed for a request
+	// to localhost over TCP.
+	assert.ErrorIs(t, err, ErrSsrfBlocked)
+}
+
+func TestDialContextNonTcpWithSsrfGuard(t *testing.T) {
+	dial := func(ctx context.Context, network string, address string) (net.Conn, error) {
+		client, server := net.Pipe()
+		go func() {
+			_, _ = io.Copy(io.Discard, server)
+		}()
+		return client, nil
+	}
+
+	c := NewClient(context.Background(), WithDialContext(dial), WithSsrfGuard(SsrfPolicy{}))
+	_, err := c.NewRequest(http.MethodGet, "http://example.com/").Send()
+	var ssrfErr *SsrfError
+	assert.True(t, errors.As(err, &ssrfErr))
+	assert.Equal(t, "connection is not TCP", ssrfErr.Reason)
+}
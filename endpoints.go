@@ -0,0 +1,212 @@
+package ask
+
+import (
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// BalanceStrategy picks the endpoint of each request among the base URLs set
+// with SetBaseUrls.
+type BalanceStrategy int
+
+const (
+	RoundRobin BalanceStrategy = iota
+	Random
+	// LeastInFlight picks the endpoint with the fewest requests in progress.
+	LeastInFlight
+	// Priority picks the first healthy endpoint, in the order given, so the
+	// others only serve as failover.
+	Priority
+)
+
+type endpointPool struct {
+	strategy    BalanceStrategy
+	maxFailures int
+	cooldown    time.Duration
+
+	mu        sync.Mutex
+	endpoints []*endpoint
+	next      int
+}
+
+type endpoint struct {
+	url          string
+	inFlight     int
+	failures     int
+	ejectedUntil time.Time
+}
+
+// SetBaseUrls spreads relative requests over several base URLs, such as
+// regional endpoints. Endpoints are ejected after consecutive failures, see
+// SetEndpointHealth, and failed idempotent requests are retried on another
+// endpoint.
+func (client *Client) SetBaseUrls(strategy BalanceStrategy, urls ...string) Client {
+	pool := &endpointPool{strategy: strategy, maxFailures: 3, cooldown: 30 * time.Second}
+	if client.endpoints != nil {
+		pool.maxFailures = client.endpoints.maxFailures
+		pool.cooldown = client.endpoints.cooldown
+	}
+	for _, u := range urls {
+		pool.endpoints = append(pool.endpoints, &endpoint{url: u})
+	}
+
+	client.endpoints = pool
+	if len(urls) > 0 {
+		client.baseUrl = urls[0]
+	}
+	return *client
+}
+
+// SetEndpointHealth ejects an endpoint after maxFailures consecutive failures,
+// connection errors or 502, 503 and 504 responses, and admits it again after
+// cooldown. The defaults are 3 failures and 30 seconds.
+func (client *Client) SetEndpointHealth(maxFailures int, cooldown time.Duration) Client {
+	if client.endpoints == nil {
+		client.endpoints = &endpointPool{}
+	}
+	client.endpoints.maxFailures = maxFailures
+	client.endpoints.cooldown = cooldown
+	return *client
+}
+
+// pick returns an endpoint not tried yet, preferring healthy ones, and counts
+// a request in flight on it.
+func (pool *endpointPool) pick(tried map[*endpoint]bool) *endpoint {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	now := time.Now()
+	var healthy []*endpoint
+	var next *endpoint
+	for _, e := range pool.endpoints {
+		if tried[e] {
+			continue
+		}
+		if !now.Before(e.ejectedUntil) {
+			healthy = append(healthy, e)
+		} else if next == nil || e.ejectedUntil.Before(next.ejectedUntil) {
+			// With every endpoint ejected, the first one back is tried.
+			next = e
+		}
+	}
+
+	if len(healthy) > 0 {
+		switch pool.strategy {
+		case Random:
+			next = healthy[rand.IntN(len(healthy))]
+		case LeastInFlight:
+			next = healthy[0]
+			for _, e := range healthy[1:] {
+				if e.inFlight < next.inFlight {
+					next = e
+				}
+			}
+		case Priority:
+			next = healthy[0]
+		default:
+			next = healthy[pool.next%len(healthy)]
+			pool.next++
+		}
+	}
+	if next != nil {
+		next.inFlight++
+	}
+	return next
+}
+
+func (pool *endpointPool) untried(tried map[*endpoint]bool) bool {
+	return len(tried) < len(pool.endpoints)
+}
+
+func (pool *endpointPool) report(e *endpoint, failed bool) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if !failed {
+		e.failures = 0
+		e.ejectedUntil = time.Time{}
+		return
+	}
+	e.failures++
+	if pool.maxFailures > 0 && e.failures >= pool.maxFailures {
+		e.ejectedUntil = time.Now().Add(pool.cooldown)
+	}
+}
+
+func (pool *endpointPool) release(e *endpoint) {
+	pool.mu.Lock()
+	e.inFlight--
+	pool.mu.Unlock()
+}
+
+func failoverStatus(statusCode int) bool {
+	return statusCode == http.StatusBadGateway || statusCode == http.StatusServiceUnavailable || statusCode == http.StatusGatewayTimeout
+}
+
+// sendToEndpoints sends req to an endpoint of the pool, and retries failed
+// idempotent requests on the other endpoints, each retry waiting for the rate
+// limiter. It returns the request of the last attempt.
+func (request *Request) sendToEndpoints(req *http.Request) (*http.Response, *http.Request, error) {
+	pool := request.client.endpoints
+	tried := map[*endpoint]bool{}
+	for {
+		if len(tried) > 0 && request.client.rateLimiter != nil {
+			if err := request.client.rateLimiter.Wait(req.Context()); err != nil {
+				return nil, req, err
+			}
+		}
+
+		e := pool.pick(tried)
+		tried[e] = true
+		request.endpoint = e.url
+
+		attempt := req.Clone(req.Context())
+		parsedUrl, err := url.Parse(e.url + request.url.String())
+		if err != nil {
+			pool.release(e)
+			return nil, attempt, err
+		}
+		attempt.URL = parsedUrl
+		if len(tried) > 1 && req.GetBody != nil {
+			attempt.Body, err = req.GetBody()
+			if err != nil {
+				pool.release(e)
+				return nil, attempt, err
+			}
+		}
+
+		response, err := request.measure(attempt)
+		failed := err != nil || failoverStatus(response.StatusCode)
+		pool.report(e, failed)
+		if response != nil && response.Body != nil {
+			response.Body = &releaseBody{ReadCloser: response.Body, release: func() { pool.release(e) }}
+		} else {
+			pool.release(e)
+		}
+
+		if !failed || !hedgeable(req) || req.Context().Err() != nil || !pool.untried(tried) {
+			return response, attempt, err
+		}
+		if response != nil && response.Body != nil {
+			response.Body.Close()
+		}
+		request.retried(RetryFailover)
+	}
+}
+
+// releaseBody calls release once, when the body is closed.
+type releaseBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (body *releaseBody) Close() error {
+	err := body.ReadCloser.Close()
+	body.once.Do(body.release)
+	return err
+}
@@ -0,0 +1,177 @@
+package ask
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func endpointServer(t *testing.T, status int) (*httptest.Server, *atomic.Int32) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"title":"post"}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &hits
+}
+
+func TestBaseUrlsRoundRobin(t *testing.T) {
+	first, firstHits := endpointServer(t, http.StatusOK)
+	second, secondHits := endpointServer(t, http.StatusOK)
+
+	c := NewClient(context.Background())
+	c.SetBaseUrls(RoundRobin, first.URL, second.URL)
+	useClient(t, c)
+
+	var endpoints []string
+	for range 4 {
+		res, err := GetJson("/posts", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		endpoints = append(endpoints, res.Endpoint)
+	}
+	assert.Equal(t, []string{first.URL, second.URL, first.URL, second.URL}, endpoints)
+	assert.Equal(t, int32(2), firstHits.Load())
+	assert.Equal(t, int32(2), secondHits.Load())
+}
+
+func TestBaseUrlsFailover(t *testing.T) {
+	down, downHits := endpointServer(t, http.StatusServiceUnavailable)
+	up, upHits := endpointServer(t, http.StatusOK)
+	metrics := NewPrometheusMetrics()
+
+	c := NewClient(context.Background())
+	c.SetBaseUrls(Priority, down.URL, up.URL)
+	c.SetEndpointHealth(2, time.Hour)
+	c.SetMetrics(metrics)
+	useClient(t, c)
+
+	for range 3 {
+		var post Post
+		res, err := GetJson("/posts/1", &post)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, up.URL, res.Endpoint)
+		assert.Equal(t, "post", post.Title)
+	}
+	// Ejected after two failures, the endpoint is no longer tried.
+	assert.Equal(t, int32(2), downHits.Load())
+	assert.Equal(t, int32(3), upHits.Load())
+	var out strings.Builder
+	assert.NoError(t, metrics.WriteText(&out))
+	assert.Contains(t, out.String(), `ask_request_retries_total{method="GET",host="`+strings.TrimPrefix(down.URL, "http://")+`",route="",reason="failover"} 2`)
+}
+
+func TestBaseUrlsReadmitAfterCooldown(t *testing.T) {
+	down, downHits := endpointServer(t, http.StatusBadGateway)
+	up, _ := endpointServer(t, http.StatusOK)
+
+	c := NewClient(context.Background())
+	c.SetBaseUrls(Priority, down.URL, up.URL)
+	c.SetEndpointHealth(1, 50*time.Millisecond)
+	useClient(t, c)
+
+	_, err := GetJson("/posts", nil)
+	assert.NoError(t, err)
+	_, err = GetJson("/posts", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), downHits.Load())
+
+	time.Sleep(60 * time.Millisecond)
+	_, err = GetJson("/posts", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), downHits.Load())
+}
+
+func TestBaseUrlsNoFailoverForPost(t *testing.T) {
+	down, _ := endpointServer(t, http.StatusServiceUnavailable)
+	up, upHits := endpointServer(t, http.StatusOK)
+
+	c := NewClient(context.Background())
+	c.SetBaseUrls(Priority, down.URL, up.URL)
+	useClient(t, c)
+
+	res, err := PostJson("/posts", []byte(`{"title":"post"}`), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+	assert.Equal(t, down.URL, res.Endpoint)
+	assert.Equal(t, int32(0), upHits.Load())
+}
+
+func TestBaseUrlsAllEjected(t *testing.T) {
+	first, firstHits := endpointServer(t, http.StatusServiceUnavailable)
+	second, secondHits := endpointServer(t, http.StatusServiceUnavailable)
+
+	c := NewClient(context.Background())
+	c.SetBaseUrls(RoundRobin, first.URL, second.URL)
+	c.SetEndpointHealth(1, time.Hour)
+	useClient(t, c)
+
+	res, err := GetJson("/posts", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+
+	// Every endpoint is ejected, requests still go to them, the first one back
+	// first.
+	res, err = GetJson("/posts", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+	assert.Equal(t, int32(2), firstHits.Load())
+	assert.Equal(t, int32(2), secondHits.Load())
+}
+
+func TestLeastInFlightPick(t *testing.T) {
+	pool := &endpointPool{strategy: LeastInFlight}
+	for _, u := range []string{"a", "b", "c"} {
+		pool.endpoints = append(pool.endpoints, &endpoint{url: u})
+	}
+
+	first := pool.pick(nil)
+	second := pool.pick(nil)
+	third := pool.pick(nil)
+	assert.Equal(t, []string{"a", "b", "c"}, []string{first.url, second.url, third.url})
+
+	pool.release(second)
+	assert.Equal(t, "b", pool.pick(nil).url)
+}
+
+func TestBaseUrlsFailoverWaitsForRateLimiter(t *testing.T) {
+	down, _ := endpointServer(t, http.StatusServiceUnavailable)
+	up, _ := endpointServer(t, http.StatusOK)
+	limiter := &countingLimiter{}
+
+	c := NewClient(context.Background())
+	c.SetBaseUrls(Priority, down.URL, up.URL)
+	c.SetRateLimiter(limiter)
+	res, err := c.NewRequest(http.MethodGet, "/posts").Send()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, up.URL, res.Endpoint)
+	assert.Equal(t, 2, limiter.calls)
+}
+
+func TestBaseUrlsInvalidEndpoint(t *testing.T) {
+	c := NewClient(context.Background())
+	c.SetBaseUrls(RoundRobin, "http://[::1")
+	_, err := c.NewRequest(http.MethodGet, "/posts").Send()
+	assert.Error(t, err)
+}
@@ -0,0 +1,39 @@
+package ask
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrMaxPages     = errors.New("ask: maximum number of pages reached")
+	ErrBodyTooLarge = errors.New("ask: response body too large")
+)
+
+// StatusError is returned when a response carries a status code that the
+// caller did not expect. Body holds the decoded error body, if any.
+type StatusError struct {
+	StatusCode int
+	Body       interface{}
+	// Response is the response with the status, when it was received by Send
+	// or a helper.
+	Response *Response
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("ask: unexpected status code %d", e.StatusCode)
+}
+
+// BodyTooLargeError is returned when a response body exceeds the configured
+// maximum size. It matches ErrBodyTooLarge with errors.Is.
+type BodyTooLargeError struct {
+	Limit int64
+}
+
+func (e *BodyTooLargeError) Error() string {
+	return fmt.Sprintf("ask: response body exceeds %d bytes", e.Limit)
+}
+
+func (e *BodyTooLargeError) Is(target error) bool {
+	return target == ErrBodyTooLarge
+}
@@ -0,0 +1,293 @@
+package ask
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// hedgeBurst caps the hedging tokens saved up while requests are fast. The
+// budget starts full, so the first requests of a client can hedge too.
+const hedgeBurst = 10
+
+// HedgePolicy sends backup copies of a slow request and uses whichever
+// response arrives first. Only idempotent requests whose body can be replayed
+// are hedged.
+type HedgePolicy struct {
+	// Delay is how long an attempt may run before the next one is sent. Zero
+	// disables hedging.
+	Delay time.Duration
+	// MaxHedges is the number of backup requests. Zero means one.
+	MaxHedges int
+	// Budget is the fraction of requests allowed to send hedges, so a slow
+	// backend does not get twice the load. Zero means 10%. Up to 10 hedges
+	// can be sent in a burst, including by the first requests.
+	Budget float64
+	// Endpoints are alternate base URLs, like "https://replica.example.com/v2",
+	// used in turn by the hedges. They replace the base URL of the client for
+	// relative request URLs, and prefix the path of absolute ones. Without
+	// them hedges go to the same URL.
+	Endpoints []string
+}
+
+// HedgeStats counts hedged requests sent through a Client.
+type HedgeStats struct {
+	// Requests is the number of requests eligible for hedging.
+	Requests int64
+	// Hedges is the number of backup requests sent.
+	Hedges int64
+	// Wins is the number of requests answered by a backup request.
+	Wins int64
+}
+
+// hedger holds the hedging budget and counters shared by copies of a Client.
+// Use newHedger, which fills the budget.
+type hedger struct {
+	mu     sync.Mutex
+	tokens float64
+
+	requests atomic.Int64
+	hedges   atomic.Int64
+	wins     atomic.Int64
+}
+
+func newHedger() *hedger {
+	return &hedger{tokens: hedgeBurst}
+}
+
+// SetHedging enables hedged requests for every request sent by the client.
+func (client *Client) SetHedging(policy HedgePolicy) Client {
+	client.hedgePolicy = &policy
+	return *client
+}
+
+// HedgeStats returns the hedging counters of the client.
+func (client *Client) HedgeStats() HedgeStats {
+	if client.hedging == nil {
+		return HedgeStats{}
+	}
+	return HedgeStats{
+		Requests: client.hedging.requests.Load(),
+		Hedges:   client.hedging.hedges.Load(),
+		Wins:     client.hedging.wins.Load(),
+	}
+}
+
+// WithHedging overrides the client hedging policy for this request. A zero
+// policy disables hedging.
+func (request *Request) WithHedging(policy HedgePolicy) *Request {
+	request.hedgePolicy = &policy
+	return request
+}
+
+func (request *Request) hedge() (HedgePolicy, bool) {
+	policy := request.hedgePolicy
+	if policy == nil {
+		policy = request.client.hedgePolicy
+	}
+	if policy == nil || policy.Delay <= 0 || request.client.hedging == nil {
+		return HedgePolicy{}, false
+	}
+	return *policy, true
+}
+
+func hedgeable(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete, http.MethodTrace:
+	default:
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+func (policy HedgePolicy) maxHedges() int {
+	if policy.MaxHedges <= 0 {
+		return 1
+	}
+	return policy.MaxHedges
+}
+
+func (policy HedgePolicy) budget() float64 {
+	if policy.Budget <= 0 {
+		return 0.1
+	}
+	return policy.Budget
+}
+
+func (h *hedger) deposit(tokens float64) {
+	h.mu.Lock()
+	h.tokens = min(h.tokens+tokens, hedgeBurst)
+	h.mu.Unlock()
+}
+
+func (h *hedger) withdraw() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.tokens < 1 {
+		return false
+	}
+	h.tokens--
+	return true
+}
+
+type hedgeResult struct {
+	attempt  int
+	response *http.Response
+	err      error
+}
+
+// do sends req and, while no attempt has answered, a backup every policy.Delay.
+// The first response wins and the other attempts are cancelled. Errors only
+// win once every attempt sent has failed. onHedge is called for every backup.
+// relative is the URL of the request before its base URL was applied, nil for
+// absolute request URLs.
+func (h *hedger) do(req *http.Request, relative *url.URL, policy HedgePolicy, send func(*http.Request) (*http.Response, error), onHedge func()) (*http.Response, error) {
+	h.requests.Add(1)
+	h.deposit(policy.budget())
+
+	results := make(chan hedgeResult, policy.maxHedges()+1)
+	var cancels []context.CancelFunc
+	launch := func(attempt int) error {
+		ctx, cancel := context.WithCancel(req.Context())
+		attemptReq, err := hedgeRequest(req.Clone(ctx), relative, attempt, policy.Endpoints)
+		if err != nil {
+			cancel()
+			return err
+		}
+		cancels = append(cancels, cancel)
+		go func() {
+			response, err := send(attemptReq)
+			results <- hedgeResult{attempt: attempt, response: response, err: err}
+		}()
+		return nil
+	}
+
+	if err := launch(0); err != nil {
+		return nil, err
+	}
+	pending := 1
+
+	timer := time.NewTimer(policy.Delay)
+	defer timer.Stop()
+
+	var firstErr error
+	for {
+		select {
+		case <-timer.C:
+			if len(cancels) > policy.maxHedges() {
+				continue
+			}
+			if !h.withdraw() {
+				// Other requests may refill the budget meanwhile.
+				timer.Reset(policy.Delay)
+				continue
+			}
+			if err := launch(len(cancels)); err != nil {
+				continue
+			}
+			h.hedges.Add(1)
+			onHedge()
+			pending++
+			timer.Reset(policy.Delay)
+		case result := <-results:
+			pending--
+			if result.err != nil {
+				cancels[result.attempt]()
+				if firstErr == nil {
+					firstErr = result.err
+				}
+				if pending == 0 {
+					return nil, firstErr
+				}
+				continue
+			}
+
+			for attempt, cancel := range cancels {
+				if attempt != result.attempt {
+					cancel()
+				}
+			}
+			go discardHedges(results, pending)
+
+			if result.attempt > 0 {
+				h.wins.Add(1)
+			}
+			response := result.response
+			if response.Body != nil {
+				response.Body = &cancelBody{ReadCloser: response.Body, cancel: cancels[result.attempt]}
+			} else {
+				cancels[result.attempt]()
+			}
+			return response, nil
+		}
+	}
+}
+
+// hedgeRequest prepares the request of a backup attempt, with a fresh body
+// and, when alternate endpoints are given, another base URL. Like with
+// SetBaseUrls, the endpoint prefixes relative request URLs; absolute ones get
+// its scheme and host, and its path as prefix.
+func hedgeRequest(req *http.Request, relative *url.URL, attempt int, endpoints []string) (*http.Request, error) {
+	if attempt == 0 {
+		return req, nil
+	}
+
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = body
+	}
+
+	if len(endpoints) > 0 {
+		base := endpoints[(attempt-1)%len(endpoints)]
+		if relative != nil {
+			hedgeUrl, err := url.Parse(base + relative.String())
+			if err != nil {
+				return nil, err
+			}
+			req.URL = hedgeUrl
+			req.Host = ""
+			return req, nil
+		}
+
+		endpoint, err := url.Parse(base)
+		if err != nil {
+			return nil, err
+		}
+		req.URL.Scheme = endpoint.Scheme
+		req.URL.Host = endpoint.Host
+		req.URL.Path = strings.TrimSuffix(endpoint.Path, "/") + req.URL.Path
+		req.URL.RawPath = ""
+		req.Host = ""
+	}
+	return req, nil
+}
+
+func discardHedges(results chan hedgeResult, pending int) {
+	for ; pending > 0; pending-- {
+		result := <-results
+		if result.err == nil && result.response.Body != nil {
+			result.response.Body.Close()
+		}
+	}
+}
+
+// cancelBody releases the context of the winning attempt once its body is
+// closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (body *cancelBody) Close() error {
+	err := body.ReadCloser.Close()
+	body.cancel()
+	return err
+}
@@ -0,0 +1,157 @@
+package ask
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// slowFirstServer stalls the first request until it is cancelled and answers
+// the others right away.
+func slowFirstServer(t *testing.T) (*httptest.Server, *atomic.Int32, chan struct{}) {
+	var hits atomic.Int32
+	cancelled := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			select {
+			case <-r.Context().Done():
+				cancelled <- struct{}{}
+			case <-time.After(5 * time.Second):
+			}
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"title":"fast"}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &hits, cancelled
+}
+
+func TestHedgingUsesFastestResponse(t *testing.T) {
+	server, hits, cancelled := slowFirstServer(t)
+
+	c := NewClient(context.Background())
+	c.SetHedging(HedgePolicy{Delay: 20 * time.Millisecond, Budget: 1})
+	useClient(t, c)
+
+	var post Post
+	res, err := GetJson(server.URL+"/search", &post)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "fast", post.Title)
+	assert.Equal(t, int32(2), hits.Load())
+	assert.Equal(t, HedgeStats{Requests: 1, Hedges: 1, Wins: 1}, c.HedgeStats())
+
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("slow attempt not cancelled")
+	}
+}
+
+func TestHedgingAlternateEndpoint(t *testing.T) {
+	slow, _, _ := slowFirstServer(t)
+	replica := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"title":"replica` + r.URL.Path + `"}`))
+	}))
+	defer replica.Close()
+
+	c := NewClient(context.Background())
+	c.SetHedging(HedgePolicy{Delay: 20 * time.Millisecond, Budget: 1, Endpoints: []string{replica.URL}})
+
+	var post Post
+	request := NewRequest(http.MethodGet, slow.URL+"/search")
+	request.setClient(c)
+	_, err := request.SendInto(&post)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "replica/search", post.Title)
+}
+
+func TestHedgingLimits(t *testing.T) {
+	server, hits, _ := slowFirstServer(t)
+
+	c := NewClient(context.Background())
+	c.SetHedging(HedgePolicy{Delay: 10 * time.Millisecond, Budget: 0.5})
+
+	// Half a token is not enough to hedge.
+	c.hedging.tokens = 0
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	request := NewRequest(http.MethodGet, server.URL)
+	request.setClient(c)
+	_, err := request.WithContext(ctx).Send()
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, int32(1), hits.Load())
+	assert.Equal(t, HedgeStats{Requests: 1}, c.HedgeStats())
+
+	assert.False(t, hedgeable(httptest.NewRequest(http.MethodPost, server.URL, nil)))
+
+	request = NewRequest(http.MethodGet, server.URL)
+	request.setClient(c)
+	_, err = request.WithHedging(HedgePolicy{}).Send()
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), hits.Load())
+	assert.Equal(t, int64(1), c.HedgeStats().Requests)
+}
+
+func TestHedgingBudget(t *testing.T) {
+	server, hits, _ := slowFirstServer(t)
+
+	c := NewClient(context.Background())
+	c.SetHedging(HedgePolicy{Delay: 10 * time.Millisecond, Budget: 0.01})
+	assert.Equal(t, float64(hedgeBurst), c.hedging.tokens)
+
+	// Without tokens, the request hedges once other requests refill the
+	// budget.
+	c.hedging.tokens = 0
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		c.hedging.deposit(1)
+	}()
+	var post Post
+	request := NewRequest(http.MethodGet, server.URL)
+	request.setClient(c)
+	_, err := request.SendInto(&post)
+	assert.NoError(t, err)
+	assert.Equal(t, "fast", post.Title)
+	assert.Equal(t, int32(2), hits.Load())
+	assert.Equal(t, int64(1), c.HedgeStats().Hedges)
+}
+
+func TestHedgingEndpointPath(t *testing.T) {
+	replica := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"title":"replica` + r.URL.RequestURI() + `"}`))
+	}))
+	defer replica.Close()
+
+	slow, _, _ := slowFirstServer(t)
+	c := NewClient(context.Background())
+	c.SetBaseUrl(slow.URL + "/api/v1")
+	c.SetHedging(HedgePolicy{Delay: 20 * time.Millisecond, Budget: 1, Endpoints: []string{replica.URL + "/api/v2"}})
+
+	var post Post
+	_, err := c.NewRequest(http.MethodGet, "/search?q=dune").SendInto(&post)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "replica/api/v2/search?q=dune", post.Title)
+
+	// Absolute URLs keep their path, under the path of the endpoint.
+	slow, _, _ = slowFirstServer(t)
+	c = NewClient(context.Background())
+	c.SetHedging(HedgePolicy{Delay: 20 * time.Millisecond, Budget: 1, Endpoints: []string{replica.URL + "/mirror/"}})
+	_, err = c.NewRequest(http.MethodGet, slow.URL+"/search").SendInto(&post)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "replica/mirror/search", post.Title)
+}
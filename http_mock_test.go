@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"testing"
 )
 
 type Post struct {
@@ -64,3 +65,11 @@ func mockClient(e *ResponseError) Client {
 
 	return *client
 }
+
+// useClient sets the global client for the test, and restores the previous
+// one afterwards.
+func useClient(t *testing.T, c *Client) {
+	previous := client
+	SetClient(*c)
+	t.Cleanup(func() { SetClient(previous) })
+}
@@ -0,0 +1,160 @@
+package ask
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Metrics receives measurements of the requests sent through a Client. It is
+// called concurrently. PrometheusMetrics is a ready-made implementation.
+type Metrics interface {
+	// RequestStarted is called when a request is about to be sent.
+	RequestStarted(labels MetricLabels)
+	// RequestFinished is called once the response body is closed, or when the
+	// request failed.
+	RequestFinished(labels MetricLabels, stats RequestStats)
+	// RequestRetried is called for hedged requests and stream reconnections.
+	RequestRetried(labels MetricLabels, reason string)
+}
+
+// MetricLabels identifies a request in metrics. Route is the path template
+// set with WithRoute, so raw paths with ids do not explode cardinality.
+type MetricLabels struct {
+	Method string
+	Host   string
+	Route  string
+}
+
+// RequestStats describes a finished request.
+type RequestStats struct {
+	// StatusCode is zero when no response was received.
+	StatusCode int
+	Err        error
+	// Latency is the time until the response headers were received.
+	Latency       time.Duration
+	BytesSent     int64
+	BytesReceived int64
+	Timings       Timings
+}
+
+// StatusClass returns the status code class, like "2xx", or "error" when no
+// response was received.
+func (stats RequestStats) StatusClass() string {
+	if stats.StatusCode == 0 {
+		return "error"
+	}
+	return strconv.Itoa(stats.StatusCode/100) + "xx"
+}
+
+const (
+	RetryHedge     = "hedge"
+	RetryReconnect = "reconnect"
+	RetryFailover  = "failover"
+)
+
+func (client *Client) SetMetrics(metrics Metrics) Client {
+	client.metrics = metrics
+	return *client
+}
+
+// WithRoute sets the route label of the request metrics, e.g. "/users/{id}".
+func (request *Request) WithRoute(route string) *Request {
+	request.route = route
+	return request
+}
+
+func (request *Request) metricLabels() MetricLabels {
+	requestUrl := request.url
+	baseUrl := request.client.baseUrl
+	if request.endpoint != "" {
+		baseUrl = request.endpoint
+	}
+	if len(baseUrl) > 0 && !requestUrl.IsAbs() {
+		if parsedUrl, err := url.Parse(baseUrl + request.url.String()); err == nil {
+			requestUrl = parsedUrl
+		}
+	}
+	return MetricLabels{Method: request.method, Host: requestUrl.Host, Route: request.route}
+}
+
+func (request *Request) retried(reason string) {
+	if request.client.metrics != nil {
+		request.client.metrics.RequestRetried(request.metricLabels(), reason)
+	}
+}
+
+type countingReader struct {
+	io.ReadCloser
+	n atomic.Int64
+}
+
+func (reader *countingReader) Read(p []byte) (int, error) {
+	n, err := reader.ReadCloser.Read(p)
+	reader.n.Add(int64(n))
+	return n, err
+}
+
+// metricsBody reports the finished request when the response body is closed.
+type metricsBody struct {
+	countingReader
+	once   sync.Once
+	finish func(received int64)
+}
+
+func (body *metricsBody) Close() error {
+	err := body.ReadCloser.Close()
+	body.once.Do(func() { body.finish(body.n.Load()) })
+	return err
+}
+
+// measure sends req through send and reports it to the client metrics.
+func (request *Request) measure(req *http.Request) (*http.Response, error) {
+	metrics := request.client.metrics
+	if metrics == nil {
+		return request.send(req)
+	}
+
+	labels := MetricLabels{Method: req.Method, Host: req.URL.Host, Route: request.route}
+	var sent *countingReader
+	if req.Body != nil && req.Body != http.NoBody {
+		sent = &countingReader{ReadCloser: req.Body}
+		req.Body = sent
+	}
+	bytesSent := func() int64 {
+		if sent == nil {
+			return 0
+		}
+		return sent.n.Load()
+	}
+
+	metrics.RequestStarted(labels)
+	start := time.Now()
+	response, err := request.send(req)
+	latency := time.Since(start)
+	if err != nil {
+		metrics.RequestFinished(labels, RequestStats{Err: err, Latency: latency, BytesSent: bytesSent(), Timings: request.trace.finish()})
+		return nil, err
+	}
+
+	trace := request.trace
+	finish := func(received int64) {
+		metrics.RequestFinished(labels, RequestStats{
+			StatusCode:    response.StatusCode,
+			Latency:       latency,
+			BytesSent:     bytesSent(),
+			BytesReceived: received,
+			Timings:       trace.finish(),
+		})
+	}
+	if response.Body == nil {
+		finish(0)
+		return response, nil
+	}
+	response.Body = &metricsBody{countingReader: countingReader{ReadCloser: response.Body}, finish: finish}
+	return response, nil
+}
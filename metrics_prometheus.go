@@ -0,0 +1,163 @@
+package ask
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultLatencyBuckets are the latency histogram buckets, in seconds, used
+// by NewPrometheusMetrics.
+var DefaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// PrometheusMetrics collects request metrics in memory and serves them in the
+// Prometheus text exposition format, without depending on the Prometheus
+// client library.
+type PrometheusMetrics struct {
+	buckets []float64
+
+	mu            sync.Mutex
+	requests      map[string]float64
+	inFlight      map[string]float64
+	retries       map[string]float64
+	bytesSent     map[string]float64
+	bytesReceived map[string]float64
+	latency       map[string]*histogram
+}
+
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewPrometheusMetrics returns an empty collector. Without buckets it uses
+// DefaultLatencyBuckets.
+func NewPrometheusMetrics(buckets ...float64) *PrometheusMetrics {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+
+	return &PrometheusMetrics{
+		buckets:       buckets,
+		requests:      map[string]float64{},
+		inFlight:      map[string]float64{},
+		retries:       map[string]float64{},
+		bytesSent:     map[string]float64{},
+		bytesReceived: map[string]float64{},
+		latency:       map[string]*histogram{},
+	}
+}
+
+func (metrics *PrometheusMetrics) RequestStarted(labels MetricLabels) {
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	metrics.inFlight[formatLabels(labels)]++
+}
+
+func (metrics *PrometheusMetrics) RequestFinished(labels MetricLabels, stats RequestStats) {
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+
+	key := formatLabels(labels)
+	metrics.inFlight[key]--
+	metrics.bytesSent[key] += float64(stats.BytesSent)
+	metrics.bytesReceived[key] += float64(stats.BytesReceived)
+
+	key = formatLabels(labels, "status", stats.StatusClass())
+	metrics.requests[key]++
+
+	h, ok := metrics.latency[key]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(metrics.buckets))}
+		metrics.latency[key] = h
+	}
+	seconds := stats.Latency.Seconds()
+	for i, bucket := range metrics.buckets {
+		if seconds <= bucket {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += seconds
+}
+
+func (metrics *PrometheusMetrics) RequestRetried(labels MetricLabels, reason string) {
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	metrics.retries[formatLabels(labels, "reason", reason)]++
+}
+
+// ServeHTTP writes the metrics in the Prometheus text format.
+func (metrics *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = metrics.WriteText(w)
+}
+
+// WriteText writes the metrics in the Prometheus text format.
+func (metrics *PrometheusMetrics) WriteText(w io.Writer) error {
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+
+	var out strings.Builder
+	writeFamily(&out, "ask_requests_total", "counter", "Requests sent, by status class.", metrics.requests)
+	writeFamily(&out, "ask_requests_in_flight", "gauge", "Requests waiting for a response or reading its body.", metrics.inFlight)
+	writeFamily(&out, "ask_request_retries_total", "counter", "Hedged requests, failovers and stream reconnections.", metrics.retries)
+	writeFamily(&out, "ask_request_bytes_sent_total", "counter", "Request body bytes sent.", metrics.bytesSent)
+	writeFamily(&out, "ask_response_bytes_received_total", "counter", "Response body bytes received.", metrics.bytesReceived)
+
+	fmt.Fprintf(&out, "# HELP ask_request_duration_seconds Time until the response headers were received.\n")
+	fmt.Fprintf(&out, "# TYPE ask_request_duration_seconds histogram\n")
+	for _, key := range sortedKeys(metrics.latency) {
+		h := metrics.latency[key]
+		for i, bucket := range metrics.buckets {
+			fmt.Fprintf(&out, "ask_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n", key, formatFloat(bucket), h.counts[i])
+		}
+		fmt.Fprintf(&out, "ask_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", key, h.count)
+		fmt.Fprintf(&out, "ask_request_duration_seconds_sum{%s} %s\n", key, formatFloat(h.sum))
+		fmt.Fprintf(&out, "ask_request_duration_seconds_count{%s} %d\n", key, h.count)
+	}
+
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+func writeFamily(out *strings.Builder, name string, kind string, help string, values map[string]float64) {
+	fmt.Fprintf(out, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(out, "# TYPE %s %s\n", name, kind)
+	for _, key := range sortedKeys(values) {
+		fmt.Fprintf(out, "%s{%s} %s\n", name, key, formatFloat(values[key]))
+	}
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatLabels(labels MetricLabels, extra ...string) string {
+	pairs := []string{"method", labels.Method, "host", labels.Host, "route", labels.Route}
+	pairs = append(pairs, extra...)
+
+	parts := make([]string, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		parts = append(parts, pairs[i]+`="`+labelEscaper.Replace(pairs[i+1])+`"`)
+	}
+	return strings.Join(parts, ",")
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
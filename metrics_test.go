@@ -0,0 +1,86 @@
+package ask
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrometheusMetrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":1}`))
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	metrics := NewPrometheusMetrics(0.1, 1)
+	c := NewClient(context.Background())
+	c.SetMetrics(metrics)
+
+	for _, id := range []string{"1", "2"} {
+		request := NewRequest(http.MethodGet, server.URL+"/users/"+id)
+		request.setClient(c)
+		_, err := request.WithRoute("/users/{id}").Send()
+		assert.NoError(t, err)
+	}
+	request := NewRequest(http.MethodPost, server.URL+"/users")
+	request.setClient(c)
+	res, err := request.WithPayloadJson([]byte(`{"name":"x"}`)).WithRoute("/users").Send()
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	request = NewRequest(http.MethodGet, "http://127.0.0.1:1/down")
+	request.setClient(c)
+	_, err = request.Send()
+	assert.Error(t, err)
+
+	recorder := httptest.NewRecorder()
+	metrics.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := recorder.Body.String()
+	assert.Contains(t, recorder.Header().Get("Content-Type"), "text/plain")
+
+	users := `method="GET",host="` + host + `",route="/users/{id}"`
+	for _, line := range []string{
+		`ask_requests_total{` + users + `,status="2xx"} 2`,
+		`ask_requests_total{method="POST",host="` + host + `",route="/users",status="4xx"} 1`,
+		`ask_requests_total{method="GET",host="127.0.0.1:1",route="",status="error"} 1`,
+		`ask_requests_in_flight{` + users + `} 0`,
+		`ask_response_bytes_received_total{` + users + `} 16`,
+		`ask_request_bytes_sent_total{method="POST",host="` + host + `",route="/users"} 12`,
+		`ask_request_duration_seconds_bucket{` + users + `,status="2xx",le="1"} 2`,
+		`ask_request_duration_seconds_bucket{` + users + `,status="2xx",le="+Inf"} 2`,
+		`ask_request_duration_seconds_count{` + users + `,status="2xx"} 2`,
+	} {
+		assert.Contains(t, body, line+"\n")
+	}
+}
+
+func TestMetricsCountRetries(t *testing.T) {
+	server, _, _ := slowFirstServer(t)
+	serverUrl, _ := url.Parse(server.URL)
+
+	metrics := NewPrometheusMetrics()
+	c := NewClient(context.Background())
+	c.SetMetrics(metrics)
+	c.SetHedging(HedgePolicy{Delay: 10 * time.Millisecond, Budget: 1})
+
+	request := NewRequest(http.MethodGet, server.URL+"/search")
+	request.setClient(c)
+	_, err := request.WithRoute("/search").Send()
+	assert.NoError(t, err)
+
+	var out strings.Builder
+	assert.NoError(t, metrics.WriteText(&out))
+	assert.Contains(t, out.String(), `ask_request_retries_total{method="GET",host="`+serverUrl.Host+`",route="/search",reason="hedge"} 1`)
+}
@@ -0,0 +1,180 @@
+package ask
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ClientOption configures the transport built by NewClient.
+type ClientOption func(config *transportConfig)
+
+type transportConfig struct {
+	client    *http.Client
+	transport *http.Transport
+	dialer    *net.Dialer
+	pins      *pinSet
+	guard     *ssrfGuard
+	dial      DialFunc
+	resolve   *resolver
+	// err is returned by every request when an option failed, e.g. to read a
+	// certificate.
+	err error
+}
+
+func newTransportConfig() *transportConfig {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	return &transportConfig{
+		client:    &http.Client{Transport: transport},
+		transport: transport,
+		dialer:    &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second},
+	}
+}
+
+func (config *transportConfig) build() HttpClient {
+	if config.err != nil {
+		return failingClient{err: config.err}
+	}
+	if config.pins != nil {
+		// Set last, so that options replacing the TLS config keep the pins.
+		config.tls().VerifyConnection = config.pins.verify
+	}
+	if config.guard != nil {
+		config.transport.Proxy = nil
+		config.dialer.Control = config.guard.control
+		config.client.Transport = &guardTransport{guard: config.guard, inner: config.transport}
+	}
+	config.transport.DialContext = config.dialContext()
+	return config.client
+}
+
+func (config *transportConfig) fail(err error) {
+	if config.err == nil {
+		config.err = err
+	}
+}
+
+// failingClient reports a configuration error on every request.
+type failingClient struct {
+	err error
+}
+
+func (client failingClient) Do(*http.Request) (*http.Response, error) {
+	return nil, client.err
+}
+
+// WithTimeout limits the whole exchange, from dialing to reading the body.
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(config *transportConfig) {
+		config.client.Timeout = timeout
+	}
+}
+
+func WithDialTimeout(timeout time.Duration) ClientOption {
+	return func(config *transportConfig) {
+		config.dialer.Timeout = timeout
+	}
+}
+
+func WithTlsHandshakeTimeout(timeout time.Duration) ClientOption {
+	return func(config *transportConfig) {
+		config.transport.TLSHandshakeTimeout = timeout
+	}
+}
+
+// WithResponseHeaderTimeout limits the wait for the response headers once the
+// request is written.
+func WithResponseHeaderTimeout(timeout time.Duration) ClientOption {
+	return func(config *transportConfig) {
+		config.transport.ResponseHeaderTimeout = timeout
+	}
+}
+
+// WithIdleConns limits the idle connections kept open in total and per host.
+// Zero keeps the defaults.
+func WithIdleConns(total int, perHost int) ClientOption {
+	return func(config *transportConfig) {
+		if total > 0 {
+			config.transport.MaxIdleConns = total
+		}
+		if perHost > 0 {
+			config.transport.MaxIdleConnsPerHost = perHost
+		}
+	}
+}
+
+// WithMaxConnsPerHost limits the connections per host, idle or not.
+func WithMaxConnsPerHost(n int) ClientOption {
+	return func(config *transportConfig) {
+		config.transport.MaxConnsPerHost = n
+	}
+}
+
+func WithIdleConnTimeout(timeout time.Duration) ClientOption {
+	return func(config *transportConfig) {
+		config.transport.IdleConnTimeout = timeout
+	}
+}
+
+// WithKeepAlive sets the TCP keep-alive period. A negative period disables
+// TCP keep-alives.
+func WithKeepAlive(period time.Duration) ClientOption {
+	return func(config *transportConfig) {
+		config.dialer.KeepAlive = period
+	}
+}
+
+// WithoutKeepAlives opens a new connection for every request.
+func WithoutKeepAlives() ClientOption {
+	return func(config *transportConfig) {
+		config.transport.DisableKeepAlives = true
+	}
+}
+
+// WithProxy sends every request through the proxy at proxyUrl.
+func WithProxy(proxyUrl string) ClientOption {
+	return func(config *transportConfig) {
+		parsedUrl, err := url.Parse(proxyUrl)
+		if err != nil {
+			config.fail(fmt.Errorf("ask: invalid proxy URL %q: %w", proxyUrl, err))
+			return
+		}
+		config.transport.Proxy = http.ProxyURL(parsedUrl)
+	}
+}
+
+// WithProxyFromEnvironment uses the HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+// variables, which is the default.
+func WithProxyFromEnvironment() ClientOption {
+	return func(config *transportConfig) {
+		config.transport.Proxy = http.ProxyFromEnvironment
+	}
+}
+
+func WithoutProxy() ClientOption {
+	return func(config *transportConfig) {
+		config.transport.Proxy = nil
+	}
+}
+
+// WithHttp2 enables or disables HTTP/2 over TLS. It is enabled by default.
+func WithHttp2(enabled bool) ClientOption {
+	return func(config *transportConfig) {
+		config.transport.ForceAttemptHTTP2 = enabled
+		if enabled {
+			config.transport.TLSNextProto = nil
+		} else {
+			config.transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+		}
+	}
+}
+
+// WithoutCompression stops asking servers for gzip responses.
+func WithoutCompression() ClientOption {
+	return func(config *transportConfig) {
+		config.transport.DisableCompression = true
+	}
+}
@@ -0,0 +1,68 @@
+package ask
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientOptions(t *testing.T) {
+	var acceptEncoding, proto string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		acceptEncoding = r.Header.Get("Accept-Encoding")
+		proto = r.Proto
+		if r.URL.Path == "/slow" {
+			time.Sleep(200 * time.Millisecond)
+		}
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+	trustServer := func(config *transportConfig) {
+		config.transport.TLSClientConfig = server.Client().Transport.(*http.Transport).TLSClientConfig.Clone()
+	}
+
+	c := NewClient(context.Background(), trustServer)
+	_, err := c.NewRequest(http.MethodGet, server.URL).Send()
+	assert.NoError(t, err)
+	assert.Equal(t, "HTTP/2.0", proto)
+	assert.Equal(t, "gzip", acceptEncoding)
+
+	c = NewClient(context.Background(), trustServer, WithHttp2(false), WithoutCompression(), WithTimeout(50*time.Millisecond))
+	_, err = c.NewRequest(http.MethodGet, server.URL).Send()
+	assert.NoError(t, err)
+	assert.Equal(t, "HTTP/1.1", proto)
+	assert.Empty(t, acceptEncoding)
+
+	_, err = c.NewRequest(http.MethodGet, server.URL+"/slow").Send()
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestClientProxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+	}))
+	defer proxy.Close()
+
+	c := NewClient(context.Background(), WithProxy(proxy.URL), WithIdleConns(10, 2), WithDialTimeout(time.Second))
+	res, err := c.NewRequest(http.MethodGet, "http://api.internal/posts").Send()
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "http://api.internal/posts", proxied)
+
+	transport := c.httpClient.(*http.Client).Transport.(*http.Transport)
+	assert.Equal(t, 2, transport.MaxIdleConnsPerHost)
+
+	c = NewClient(context.Background(), WithProxy(proxy.URL), WithoutProxy())
+	transport = c.httpClient.(*http.Client).Transport.(*http.Transport)
+	assert.Nil(t, transport.Proxy)
+
+	c = NewClient(context.Background(), WithProxy("http://proxy\x7f:8080"))
+	_, err = c.NewRequest(http.MethodGet, "http://api.internal/posts").Send()
+	assert.ErrorContains(t, err, "invalid proxy URL")
+}
@@ -0,0 +1,292 @@
+package ask
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// PageStrategy decides which URL to fetch after each page. Next returns a nil
+// URL once there are no more pages.
+type PageStrategy interface {
+	First(pageUrl *url.URL) *url.URL
+	Next(pageUrl *url.URL, header http.Header, body []byte, items int) (*url.URL, error)
+}
+
+type PageOption func(*pageConfig)
+
+type pageConfig struct {
+	maxPages int
+	itemsKey string
+}
+
+// MaxPages stops the iteration with ErrMaxPages after n pages have been fetched.
+func MaxPages(n int) PageOption {
+	return func(config *pageConfig) {
+		config.maxPages = n
+	}
+}
+
+// ItemsKey reads the page items from a top-level key of the response body
+// instead of expecting the body to be an array.
+func ItemsKey(key string) PageOption {
+	return func(config *pageConfig) {
+		config.itemsKey = key
+	}
+}
+
+// Paginate fetches every page of a listing endpoint with the global client and
+// yields its items one by one. The iteration stops at the first error.
+func Paginate[T any](ctx context.Context, requestUrl string, strategy PageStrategy, options ...PageOption) iter.Seq2[T, error] {
+	return PaginateWith[T](ctx, &client, requestUrl, strategy, options...)
+}
+
+// PaginateWith is Paginate with the given client.
+func PaginateWith[T any](ctx context.Context, c *Client, requestUrl string, strategy PageStrategy, options ...PageOption) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		config := pageConfig{}
+		for _, option := range options {
+			option(&config)
+		}
+
+		pageUrl, err := url.Parse(requestUrl)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		pageUrl = strategy.First(pageUrl)
+
+		for page := 0; pageUrl != nil; page++ {
+			if config.maxPages > 0 && page >= config.maxPages {
+				yield(zero, ErrMaxPages)
+				return
+			}
+			if err := ctx.Err(); err != nil {
+				yield(zero, err)
+				return
+			}
+
+			request := NewRequest(http.MethodGet, pageUrl.String())
+			request.setClient(c)
+			response, err := request.WithContext(ctx).AcceptJson().Send()
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			if response.StatusCode < 200 || response.StatusCode >= 300 {
+				yield(zero, &StatusError{StatusCode: response.StatusCode, Body: response.Error, Response: response})
+				return
+			}
+
+			items, err := pageItems[T](response, config.itemsKey)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+
+			pageUrl, err = strategy.Next(pageUrl, response.Header, response.body, len(items))
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+		}
+	}
+}
+
+func pageItems[T any](response *Response, itemsKey string) ([]T, error) {
+	var items []T
+	if len(response.body) == 0 {
+		return items, nil
+	}
+
+	if itemsKey == "" {
+		err := response.Decode(&items)
+		return items, err
+	}
+
+	var envelope map[string]any
+	err := response.Decode(&envelope)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := envelope[itemsKey]
+	if !ok || value == nil {
+		return items, nil
+	}
+
+	// Re-encode the nested value so it is decoded by the same codec as the page.
+	codec := response.client.codec(response.Header.Get("Content-Type"))
+	data, err := codec.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	err = codec.Unmarshal(data, &items)
+	return items, err
+}
+
+type linkStrategy struct{}
+
+// LinkHeader follows the RFC 8288 Link header with rel="next".
+func LinkHeader() PageStrategy {
+	return linkStrategy{}
+}
+
+func (linkStrategy) First(pageUrl *url.URL) *url.URL {
+	return pageUrl
+}
+
+func (linkStrategy) Next(pageUrl *url.URL, header http.Header, _ []byte, _ int) (*url.URL, error) {
+	next, ok := ParseLinkHeader(header)["next"]
+	if !ok {
+		return nil, nil
+	}
+
+	nextUrl, err := url.Parse(next)
+	if err != nil {
+		return nil, err
+	}
+	return pageUrl.ResolveReference(nextUrl), nil
+}
+
+// ParseLinkHeader returns the target of every relation found in the Link
+// headers, keyed by relation type.
+func ParseLinkHeader(header http.Header) map[string]string {
+	links := map[string]string{}
+	for _, value := range header.Values("Link") {
+		for len(value) > 0 {
+			start := strings.IndexByte(value, '<')
+			end := strings.IndexByte(value, '>')
+			if start < 0 || end < start {
+				break
+			}
+			target := value[start+1 : end]
+			value = value[end+1:]
+
+			params := value
+			if next := strings.IndexByte(value, '<'); next >= 0 {
+				params = value[:next]
+				value = value[next:]
+			} else {
+				value = ""
+			}
+
+			for _, param := range strings.Split(params, ";") {
+				key, val, found := strings.Cut(strings.TrimSpace(param), "=")
+				if !found || !strings.EqualFold(strings.TrimSpace(key), "rel") {
+					continue
+				}
+				val = strings.Trim(strings.TrimSpace(strings.TrimRight(strings.TrimSpace(val), ",")), `"`)
+				for _, rel := range strings.Fields(val) {
+					rel = strings.ToLower(rel)
+					if _, exists := links[rel]; !exists {
+						links[rel] = target
+					}
+				}
+			}
+		}
+	}
+	return links
+}
+
+type cursorStrategy struct {
+	param   string
+	extract func(body []byte) (string, error)
+}
+
+// Cursor reads the next cursor from the response body with extract and sends
+// it in the param query parameter. An empty cursor ends the iteration.
+func Cursor(param string, extract func(body []byte) (string, error)) PageStrategy {
+	return cursorStrategy{param: param, extract: extract}
+}
+
+func (strategy cursorStrategy) First(pageUrl *url.URL) *url.URL {
+	return pageUrl
+}
+
+func (strategy cursorStrategy) Next(pageUrl *url.URL, _ http.Header, body []byte, _ int) (*url.URL, error) {
+	cursor, err := strategy.extract(body)
+	if err != nil || cursor == "" {
+		return nil, err
+	}
+	return withQuery(pageUrl, strategy.param, cursor), nil
+}
+
+type pageNumberStrategy struct {
+	param string
+	start int
+}
+
+// PageNumber increments the param query parameter starting from start, until a
+// page comes back empty.
+func PageNumber(param string, start int) PageStrategy {
+	return pageNumberStrategy{param: param, start: start}
+}
+
+func (strategy pageNumberStrategy) First(pageUrl *url.URL) *url.URL {
+	if pageUrl.Query().Has(strategy.param) {
+		return pageUrl
+	}
+	return withQuery(pageUrl, strategy.param, strconv.Itoa(strategy.start))
+}
+
+func (strategy pageNumberStrategy) Next(pageUrl *url.URL, _ http.Header, _ []byte, items int) (*url.URL, error) {
+	if items == 0 {
+		return nil, nil
+	}
+
+	page, err := strconv.Atoi(pageUrl.Query().Get(strategy.param))
+	if err != nil {
+		return nil, fmt.Errorf("ask: invalid page number: %w", err)
+	}
+	return withQuery(pageUrl, strategy.param, strconv.Itoa(page+1)), nil
+}
+
+type offsetStrategy struct {
+	offsetParam string
+	limitParam  string
+	limit       int
+}
+
+// Offset requests limit items at a time, advancing the offsetParam query
+// parameter until a page returns fewer items than limit.
+func Offset(offsetParam string, limitParam string, limit int) PageStrategy {
+	return offsetStrategy{offsetParam: offsetParam, limitParam: limitParam, limit: limit}
+}
+
+func (strategy offsetStrategy) First(pageUrl *url.URL) *url.URL {
+	pageUrl = withQuery(pageUrl, strategy.limitParam, strconv.Itoa(strategy.limit))
+	if pageUrl.Query().Has(strategy.offsetParam) {
+		return pageUrl
+	}
+	return withQuery(pageUrl, strategy.offsetParam, "0")
+}
+
+func (strategy offsetStrategy) Next(pageUrl *url.URL, _ http.Header, _ []byte, items int) (*url.URL, error) {
+	if items < strategy.limit || items == 0 {
+		return nil, nil
+	}
+
+	offset, err := strconv.Atoi(pageUrl.Query().Get(strategy.offsetParam))
+	if err != nil {
+		return nil, fmt.Errorf("ask: invalid offset: %w", err)
+	}
+	return withQuery(pageUrl, strategy.offsetParam, strconv.Itoa(offset+items)), nil
+}
+
+func withQuery(pageUrl *url.URL, key string, value string) *url.URL {
+	next := *pageUrl
+	query := next.Query()
+	query.Set(key, value)
+	next.RawQuery = query.Encode()
+	return &next
+}
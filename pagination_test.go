@@ -0,0 +1,171 @@
+package ask
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type countingLimiter struct {
+	calls int
+}
+
+func (limiter *countingLimiter) Wait(ctx context.Context) error {
+	limiter.calls++
+	return ctx.Err()
+}
+
+func collect[T any](t *testing.T, seq func(func(T, error) bool)) ([]T, error) {
+	t.Helper()
+	var items []T
+	for item, err := range seq {
+		if err != nil {
+			return items, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func TestPaginateLinkHeader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page < 2 {
+			w.Header().Set("Link", fmt.Sprintf(`</posts?page=%d>; rel="next", </posts?page=0>; rel="first"`, page+1))
+		}
+		_ = json.NewEncoder(w).Encode([]Post{{Id: page*2 + 1}, {Id: page*2 + 2}})
+	}))
+	defer server.Close()
+
+	limiter := &countingLimiter{}
+	c := NewClient(context.Background())
+	c.SetBaseUrl(server.URL)
+	c.SetRateLimiter(limiter)
+
+	posts, err := collect(t, PaginateWith[Post](context.Background(), c, "/posts?page=0", LinkHeader()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, posts, 6)
+	assert.Equal(t, 6, posts[5].Id)
+	assert.Equal(t, 3, limiter.calls)
+}
+
+func TestPaginateCursor(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next := ""
+		if r.URL.Query().Get("cursor") == "" {
+			next = "abc"
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"data":        []Post{{Title: "cursor " + r.URL.Query().Get("cursor")}},
+			"next_cursor": next,
+		})
+	}))
+	defer server.Close()
+
+	useClient(t, NewClient(context.Background()))
+	extract := func(body []byte) (string, error) {
+		var page struct {
+			NextCursor string `json:"next_cursor"`
+		}
+		err := json.Unmarshal(body, &page)
+		return page.NextCursor, err
+	}
+
+	posts, err := collect(t, Paginate[Post](context.Background(), server.URL, Cursor("cursor", extract), ItemsKey("data")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []Post{{Title: "cursor "}, {Title: "cursor abc"}}, posts)
+}
+
+func TestPaginateOffsetAndMaxPages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		var posts []Post
+		for i := offset; i < offset+limit && i < 5; i++ {
+			posts = append(posts, Post{Id: i})
+		}
+		_ = json.NewEncoder(w).Encode(posts)
+	}))
+	defer server.Close()
+
+	useClient(t, NewClient(context.Background()))
+
+	posts, err := collect(t, Paginate[Post](context.Background(), server.URL, Offset("offset", "limit", 2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, posts, 5)
+
+	posts, err = collect(t, Paginate[Post](context.Background(), server.URL, Offset("offset", "limit", 2), MaxPages(2)))
+	assert.True(t, errors.Is(err, ErrMaxPages))
+	assert.Len(t, posts, 4)
+}
+
+func TestPaginatePageNumberCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]Post{{Id: 1}})
+	}))
+	defer server.Close()
+
+	useClient(t, NewClient(context.Background()))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var count int
+	var err error
+	for _, err = range Paginate[Post](ctx, server.URL, PageNumber("page", 1)) {
+		if err != nil {
+			break
+		}
+		count++
+		if count == 3 {
+			cancel()
+		}
+	}
+
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Equal(t, 3, count)
+}
+
+func TestParseLinkHeader(t *testing.T) {
+	header := http.Header{}
+	header.Add("Link", `<https://api.example.com/items?page=2>; rel="next last", <https://api.example.com/items?page=1>; rel=prev`)
+
+	links := ParseLinkHeader(header)
+
+	assert.Equal(t, "https://api.example.com/items?page=2", links["next"])
+	assert.Equal(t, "https://api.example.com/items?page=2", links["last"])
+	assert.Equal(t, "https://api.example.com/items?page=1", links["prev"])
+}
+
+func TestPaginateErrorType(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"code":"bad_cursor","message":"cursor expired"}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(context.Background())
+	c.SetErrorType(func() error { return &ApiError{} })
+	_, err := collect(t, PaginateWith[Post](context.Background(), c, server.URL, LinkHeader()))
+	assert.Equal(t, &ApiError{Code: "bad_cursor", Message: "cursor expired"}, err)
+
+	_, err = collect(t, PaginateWith[Post](context.Background(), NewClient(context.Background()), server.URL, LinkHeader()))
+	var statusErr *StatusError
+	assert.True(t, errors.As(err, &statusErr))
+	assert.Equal(t, http.StatusBadRequest, statusErr.Response.StatusCode)
+}
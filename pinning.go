@@ -0,0 +1,118 @@
+package ask
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"slices"
+	"strings"
+)
+
+var ErrPinMismatch = errors.New("ask: certificate pin mismatch")
+
+// PinError is returned when no certificate of the chain served by Host
+// matches its pins.
+type PinError struct {
+	Host string
+	// Pins are the SPKI pins of the certificates served.
+	Pins []string
+}
+
+func (err *PinError) Error() string {
+	return fmt.Sprintf("ask: no pinned key for %s in served chain %s", err.Host, strings.Join(err.Pins, ", "))
+}
+
+func (err *PinError) Is(target error) bool {
+	return target == ErrPinMismatch
+}
+
+// SpkiPin returns the pin of a certificate: "sha256/" followed by the base64
+// SHA-256 hash of its public key, the format of HPKP and of
+// "openssl x509 -pubkey | openssl pkey -pubin -outform der | openssl dgst -sha256 -binary | base64".
+func SpkiPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return "sha256/" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+type pinSet struct {
+	hosts      map[string]map[string]bool
+	reportOnly bool
+}
+
+// WithPins pins the public keys of host, the name of its TLS certificate. The
+// connection is accepted when any certificate of the chain matches one of the
+// pins, so backup pins can be listed ahead of a key rotation. Pins are in the
+// SpkiPin format; the "sha256/" prefix is optional. Standard certificate
+// verification still applies, including custom CAs.
+func WithPins(host string, pins ...string) ClientOption {
+	return func(config *transportConfig) {
+		if net.ParseIP(host) != nil {
+			config.fail(fmt.Errorf("ask: cannot pin %s, IP address hosts send no server name", host))
+			return
+		}
+
+		set := config.pinSet()
+		host = strings.ToLower(host)
+		if set.hosts[host] == nil {
+			set.hosts[host] = map[string]bool{}
+		}
+		for _, pin := range pins {
+			set.hosts[host][strings.TrimPrefix(pin, "sha256/")] = true
+		}
+	}
+}
+
+// WithPinReportOnly logs pin mismatches instead of failing the connection, to
+// try out pins safely.
+func WithPinReportOnly() ClientOption {
+	return func(config *transportConfig) {
+		config.pinSet().reportOnly = true
+	}
+}
+
+func (config *transportConfig) pinSet() *pinSet {
+	if config.pins == nil {
+		config.pins = &pinSet{hosts: map[string]map[string]bool{}}
+	}
+	return config.pins
+}
+
+func (set *pinSet) verify(state tls.ConnectionState) error {
+	host := strings.ToLower(state.ServerName)
+	pins, ok := set.hosts[host]
+	if !ok {
+		return nil
+	}
+
+	// Only verified chains count: any server can send a copy of a pinned
+	// intermediate certificate. Without verification, only the leaf counts.
+	chains := state.VerifiedChains
+	if len(chains) == 0 && len(state.PeerCertificates) > 0 {
+		chains = [][]*x509.Certificate{state.PeerCertificates[:1]}
+	}
+
+	var served []string
+	for _, chain := range chains {
+		for _, cert := range chain {
+			pin := SpkiPin(cert)
+			if pins[strings.TrimPrefix(pin, "sha256/")] {
+				return nil
+			}
+			if !slices.Contains(served, pin) {
+				served = append(served, pin)
+			}
+		}
+	}
+
+	err := &PinError{Host: host, Pins: served}
+	if set.reportOnly {
+		log.Println(err.Error() + " (report only)")
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,67 @@
+package ask
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCertificatePinning(t *testing.T) {
+	ca := newTestCa(t)
+	certPem, keyPem := ca.issue(t, "localhost")
+	cert, _ := tls.X509KeyPair(certPem, keyPem)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	server.StartTLS()
+	defer server.Close()
+	serverUrl := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+
+	block, _ := pem.Decode(certPem)
+	leaf, _ := x509.ParseCertificate(block.Bytes)
+	leafPin, caPin := SpkiPin(leaf), SpkiPin(ca.cert)
+	wrongPin := "sha256/AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="
+
+	send := func(opts ...ClientOption) error {
+		c := NewClient(context.Background(), append([]ClientOption{WithCaPem(ca.pem), WithoutKeepAlives()}, opts...)...)
+		_, err := c.NewRequest(http.MethodGet, serverUrl).Send()
+		return err
+	}
+
+	assert.NoError(t, send(WithPins("localhost", leafPin)))
+	assert.NoError(t, send(WithPins("LOCALHOST", wrongPin, strings.TrimPrefix(caPin, "sha256/"))))
+	assert.NoError(t, send(WithPins("example.com", wrongPin)))
+
+	err := send(WithPins("localhost", wrongPin))
+	assert.ErrorIs(t, err, ErrPinMismatch)
+	var pinErr *PinError
+	if assert.True(t, errors.As(err, &pinErr)) {
+		assert.Equal(t, "localhost", pinErr.Host)
+		assert.Equal(t, []string{leafPin, caPin}, pinErr.Pins)
+	}
+
+	// An option replacing the TLS config after WithPins keeps the pins.
+	replaceTls := func(config *transportConfig) {
+		config.transport.TLSClientConfig = &tls.Config{RootCAs: config.tls().RootCAs}
+	}
+	assert.ErrorIs(t, send(WithPins("localhost", wrongPin), replaceTls), ErrPinMismatch)
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+	assert.NoError(t, send(WithPins("localhost", wrongPin), WithPinReportOnly()))
+	assert.Contains(t, logs.String(), "no pinned key for localhost")
+	assert.Contains(t, logs.String(), "(report only)")
+
+	assert.ErrorContains(t, send(WithPins("127.0.0.1", leafPin)), "cannot pin 127.0.0.1")
+}
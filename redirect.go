@@ -0,0 +1,208 @@
+package ask
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+var ErrTooManyRedirects = errors.New("ask: too many redirects")
+
+// RedirectPolicy decides which redirects are followed. Redirect responses not
+// followed are returned as is.
+type RedirectPolicy struct {
+	// MaxHops is the most redirects followed, 10 by default. Past it, the
+	// request fails with ErrTooManyRedirects.
+	MaxHops int
+	// SameHost only follows redirects to the host of the request.
+	SameHost bool
+	// Never follows any redirect.
+	Never bool
+	// KeepMethod keeps the method and payload on 301 and 302 redirects,
+	// which otherwise turn a request other than GET or HEAD into a GET
+	// without payload. 303 always does, 307 and 308 never do.
+	KeepMethod bool
+}
+
+// Redirect is a hop of the redirects followed by a request.
+type Redirect struct {
+	Method     string
+	Url        string
+	StatusCode int
+	// Location is the URL redirected to.
+	Location string
+}
+
+const defaultMaxRedirects = 10
+
+// headers only sent to the origin of the request.
+var credentialHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Cookie2"}
+
+// SetRedirectPolicy replaces how redirects are followed. Whatever the policy,
+// credentials are not forwarded to another origin and payloads are replayed
+// on 307 and 308 redirects.
+func (client *Client) SetRedirectPolicy(policy RedirectPolicy) Client {
+	client.redirectPolicy = &policy
+	return *client
+}
+
+// WithRedirectPolicy overrides the redirect policy of the client for this
+// request.
+func (request *Request) WithRedirectPolicy(policy RedirectPolicy) *Request {
+	request.redirectPolicy = &policy
+	return request
+}
+
+func (request *Request) redirects() (RedirectPolicy, bool) {
+	if request.redirectPolicy != nil {
+		return *request.redirectPolicy, true
+	}
+	if request.client.redirectPolicy != nil {
+		return *request.client.redirectPolicy, true
+	}
+	return RedirectPolicy{}, false
+}
+
+// do sends req with the HTTP client of the request, following redirects. The
+// request of each redirect links to the response before it, like those
+// followed by http.Client.
+func (request *Request) do(req *http.Request) (*http.Response, error) {
+	policy, ok := request.redirects()
+	httpClient := request.client.sender()
+	if client, isHttpClient := httpClient.(*http.Client); isHttpClient {
+		if client.CheckRedirect != nil && !ok {
+			// The client has its own redirect rules.
+			return client.Do(req)
+		}
+		copied := *client
+		copied.CheckRedirect = func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+		httpClient = &copied
+	}
+
+	maxHops := policy.MaxHops
+	if maxHops == 0 {
+		maxHops = defaultMaxRedirects
+	}
+	for hops := 0; ; hops++ {
+		response, err := httpClient.Do(req)
+		if err != nil || policy.Never {
+			return response, err
+		}
+		if response.Request == nil {
+			response.Request = req
+		}
+
+		next := nextRedirect(req, response, policy)
+		if next == nil {
+			return response, nil
+		}
+		if response.Body != nil {
+			_, _ = io.CopyN(io.Discard, response.Body, 2<<10)
+			response.Body.Close()
+		}
+		if hops == maxHops {
+			if next.Body != nil {
+				next.Body.Close()
+			}
+			return nil, fmt.Errorf("%w: stopped after %d redirects to %s", ErrTooManyRedirects, maxHops, next.URL.Redacted())
+		}
+		req = next
+	}
+}
+
+// nextRedirect returns the request following the redirect response, or nil
+// when it should not be followed.
+func nextRedirect(req *http.Request, response *http.Response, policy RedirectPolicy) *http.Request {
+	replay := true
+	method := req.Method
+	switch response.StatusCode {
+	case http.StatusMovedPermanently, http.StatusFound:
+		if !policy.KeepMethod && method != http.MethodGet && method != http.MethodHead {
+			method = http.MethodGet
+			replay = false
+		}
+	case http.StatusSeeOther:
+		if method != http.MethodHead {
+			method = http.MethodGet
+		}
+		replay = false
+	case http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+	default:
+		return nil
+	}
+
+	location, err := response.Location()
+	if err != nil {
+		return nil
+	}
+	if policy.SameHost && !strings.EqualFold(location.Hostname(), req.URL.Hostname()) {
+		return nil
+	}
+
+	next := req.Clone(req.Context())
+	next.Method = method
+	next.URL = location
+	next.Response = response
+	if location.Host != req.URL.Host {
+		next.Host = ""
+	}
+	if !sameOrigin(req.URL, location) {
+		for _, header := range credentialHeaders {
+			next.Header.Del(header)
+		}
+	}
+
+	switch {
+	case !replay:
+		next.Body = nil
+		next.GetBody = nil
+		next.ContentLength = 0
+		next.Header.Del("Content-Type")
+		next.Header.Del("Content-Length")
+	case req.GetBody != nil:
+		next.Body, err = req.GetBody()
+		if err != nil {
+			return nil
+		}
+	case req.Body != nil && req.Body != http.NoBody:
+		// The payload cannot be sent again.
+		return nil
+	}
+	return next
+}
+
+func sameOrigin(a *url.URL, b *url.URL) bool {
+	return strings.EqualFold(a.Scheme, b.Scheme) && strings.EqualFold(a.Hostname(), b.Hostname()) && originPort(a) == originPort(b)
+}
+
+func originPort(u *url.URL) string {
+	if port := u.Port(); port != "" {
+		return port
+	}
+	if strings.EqualFold(u.Scheme, "https") {
+		return "443"
+	}
+	return "80"
+}
+
+// redirectsOf lists the redirects that led to response, first to last.
+func redirectsOf(response *http.Response) []Redirect {
+	var redirects []Redirect
+	for req := response.Request; req != nil && req.Response != nil && req.Response.Request != nil; req = req.Response.Request {
+		previous := req.Response
+		redirects = append(redirects, Redirect{
+			Method:     previous.Request.Method,
+			Url:        previous.Request.URL.String(),
+			StatusCode: previous.StatusCode,
+			Location:   req.URL.String(),
+		})
+	}
+	slices.Reverse(redirects)
+	return redirects
+}
@@ -0,0 +1,116 @@
+package ask
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// redirectServer redirects /from to location with status, and echoes the
+// method, Authorization header and payload of other requests.
+func redirectServer(t *testing.T, status int, location func(server *httptest.Server) string) *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/from" {
+			http.Redirect(w, r, location(server), status)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"title":"` + r.Method + ` ` + r.Header.Get("Authorization") + ` ` + string(body) + `"}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func sendRedirected(t *testing.T, c *Client, method string, url string, payload string) (*Response, Post) {
+	request := NewRequest(method, url)
+	request.setClient(c)
+	request.Header.Set("Authorization", "Bearer secret")
+	if payload != "" {
+		request.WithPayloadJson([]byte(payload))
+	}
+
+	var post Post
+	res, err := request.SendInto(&post)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res, post
+}
+
+func TestRedirectStripsCredentialsAcrossOrigins(t *testing.T) {
+	target := redirectServer(t, http.StatusFound, nil)
+	server := redirectServer(t, http.StatusFound, func(*httptest.Server) string { return target.URL + "/to" })
+
+	res, post := sendRedirected(t, NewClient(context.Background()), http.MethodGet, server.URL+"/from", "")
+	assert.Equal(t, "GET  ", post.Title)
+	assert.Equal(t, []Redirect{{Method: http.MethodGet, Url: server.URL + "/from", StatusCode: http.StatusFound, Location: target.URL + "/to"}}, res.Redirects)
+}
+
+func TestRedirectKeepsCredentialsOnSameOrigin(t *testing.T) {
+	server := redirectServer(t, http.StatusMovedPermanently, func(server *httptest.Server) string { return server.URL + "/to" })
+
+	_, post := sendRedirected(t, NewClient(context.Background()), http.MethodGet, server.URL+"/from", "")
+	assert.Equal(t, "GET Bearer secret ", post.Title)
+}
+
+func TestRedirectReplaysPayload(t *testing.T) {
+	server := redirectServer(t, http.StatusTemporaryRedirect, func(server *httptest.Server) string { return server.URL + "/to" })
+
+	res, post := sendRedirected(t, NewClient(context.Background()), http.MethodPost, server.URL+"/from", `1`)
+	assert.Equal(t, "POST Bearer secret 1", post.Title)
+	assert.Len(t, res.Redirects, 1)
+}
+
+func TestRedirectMethodRewriting(t *testing.T) {
+	server := redirectServer(t, http.StatusFound, func(server *httptest.Server) string { return server.URL + "/to" })
+
+	_, post := sendRedirected(t, NewClient(context.Background()), http.MethodPost, server.URL+"/from", `1`)
+	assert.Equal(t, "GET Bearer secret ", post.Title)
+
+	c := NewClient(context.Background())
+	c.SetRedirectPolicy(RedirectPolicy{KeepMethod: true})
+	_, post = sendRedirected(t, c, http.MethodPost, server.URL+"/from", `1`)
+	assert.Equal(t, "POST Bearer secret 1", post.Title)
+}
+
+func TestRedirectPolicies(t *testing.T) {
+	target := redirectServer(t, http.StatusFound, nil)
+	server := redirectServer(t, http.StatusFound, func(*httptest.Server) string { return strings.Replace(target.URL, "127.0.0.1", "localhost", 1) + "/to" })
+
+	c := NewClient(context.Background())
+	c.SetRedirectPolicy(RedirectPolicy{SameHost: true})
+	res, _ := sendRedirected(t, c, http.MethodGet, server.URL+"/from", "")
+	assert.Equal(t, http.StatusFound, res.StatusCode)
+	assert.Empty(t, res.Redirects)
+
+	request := NewRequest(http.MethodGet, server.URL+"/from")
+	request.setClient(c)
+	res, err := request.WithRedirectPolicy(RedirectPolicy{Never: true}).Send()
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusFound, res.StatusCode)
+
+	request = NewRequest(http.MethodGet, server.URL+"/from")
+	request.setClient(c)
+	res, err = request.WithRedirectPolicy(RedirectPolicy{}).Send()
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+}
+
+func TestRedirectMaxHops(t *testing.T) {
+	server := redirectServer(t, http.StatusFound, func(server *httptest.Server) string { return server.URL + "/from" })
+
+	c := NewClient(context.Background())
+	c.SetRedirectPolicy(RedirectPolicy{MaxHops: 3})
+	request := NewRequest(http.MethodGet, server.URL+"/from")
+	request.setClient(c)
+	_, err := request.Send()
+	assert.True(t, errors.Is(err, ErrTooManyRedirects))
+}
@@ -7,17 +7,30 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"net/http/httptrace"
 	"net/url"
+	"strings"
 )
 
 type QueryParams map[string]any
 
 type Request struct {
 	client  *Client
+	ctx     context.Context
 	method  string
 	url     *url.URL
 	Header  http.Header
-	payload *bytes.Buffer
+	payload io.Reader
+
+	maxResponseSize int64
+	hedgePolicy     *HedgePolicy
+	trace           *timingTrace
+	route           string
+	endpoint        string
+	redirectPolicy  *RedirectPolicy
+	status          statusRules
+	// streaming is set for bodies read as they arrive, like event streams.
+	streaming bool
 }
 
 func NewRequest(method string, requestUrl string) *Request {
@@ -37,6 +50,12 @@ func NewRequest(method string, requestUrl string) *Request {
 	}
 }
 
+// NewRequest returns a request sent through the client, with its base URL,
+// default headers and policies.
+func (client *Client) NewRequest(method string, requestUrl string) *Request {
+	return NewRequest(method, requestUrl).setClient(client)
+}
+
 func (request *Request) setClient(client *Client) *Request {
 	if client != nil {
 		request.client = client
@@ -46,24 +65,71 @@ func (request *Request) setClient(client *Client) *Request {
 	return request
 }
 
+func (request *Request) WithContext(ctx context.Context) *Request {
+	request.ctx = ctx
+	return request
+}
+
+func (request *Request) context() context.Context {
+	if request.ctx != nil {
+		return request.ctx
+	}
+	if request.client != nil && request.client.ctx != nil {
+		return request.client.ctx
+	}
+	return context.Background()
+}
+
+// WithMaxResponseSize overrides the client limit on the decoded response body
+// size for this request. A negative n removes the limit.
+func (request *Request) WithMaxResponseSize(n int64) *Request {
+	request.maxResponseSize = n
+	return request
+}
+
 func (request *Request) WithPayloadJson(json []byte) *Request {
 	request.Header.Set("Content-Type", "application/json")
 	request.payload = bytes.NewBuffer(json)
 	return request
 }
 
+// WithPayloadStream sends body as it is read, without buffering it, e.g. the
+// reader returned by JsonLines.
+func (request *Request) WithPayloadStream(contentType string, body io.Reader) *Request {
+	request.Header.Set("Content-Type", contentType)
+	request.payload = body
+	return request
+}
+
 func (request *Request) AcceptJson() *Request {
 	request.Header.Set("Accept", "application/json")
 	return request
 }
 
+// WithPayload encodes v with the codec registered for contentType.
+func (request *Request) WithPayload(contentType string, v any) (*Request, error) {
+	data, err := request.client.codec(contentType).Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("Content-Type", contentType)
+	request.payload = bytes.NewBuffer(data)
+	return request, nil
+}
+
+func (request *Request) Accept(contentTypes ...string) *Request {
+	request.Header.Set("Accept", strings.Join(contentTypes, ", "))
+	return request
+}
+
 func (request *Request) SendRaw() (*http.Response, error) {
 	var req *http.Request
 	var err error
 	if request.payload != nil {
-		req, err = http.NewRequest(request.method, request.url.String(), request.payload)
+		req, err = http.NewRequestWithContext(request.context(), request.method, request.url.String(), request.payload)
 	} else {
-		req, err = http.NewRequest(request.method, request.url.String(), nil)
+		req, err = http.NewRequestWithContext(request.context(), request.method, request.url.String(), nil)
 	}
 	if err != nil {
 		return nil, err
@@ -78,18 +144,40 @@ func (request *Request) SendRaw() (*http.Response, error) {
 		request.setClient(nil)
 	}
 
-	if len(request.client.baseUrl) > 0 {
+	request.endpoint = ""
+	if len(request.client.baseUrl) > 0 && !request.url.IsAbs() {
 		parsedUrl, err := url.Parse(request.client.baseUrl + request.url.String())
 		if err != nil {
-			log.Panicln(err)
+			return nil, err
 		}
 		req.URL = parsedUrl
+		request.endpoint = request.client.baseUrl
+	}
+
+	if request.client.rateLimiter != nil {
+		err = request.client.rateLimiter.Wait(req.Context())
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	response, err := request.client.httpClient.Do(req)
+	request.trace = newTimingTrace()
+	ctx := request.client.startTrace(req.Context())
+	req = req.WithContext(httptrace.WithClientTrace(ctx, request.trace.clientTrace()))
+
+	var response *http.Response
+	if pool := request.client.endpoints; pool != nil && len(pool.endpoints) > 0 && !request.url.IsAbs() {
+		response, req, err = request.sendToEndpoints(req)
+	} else {
+		response, err = request.measure(req)
+	}
 	if err != nil {
 		return nil, err
 	}
+	if response.Request == nil {
+		response.Request = req
+	}
+	request.trace.responded()
 
 	if request.client.verbose {
 		reqHeader, err := json.Marshal(req.Header)
@@ -120,42 +208,105 @@ func (request *Request) SendRaw() (*http.Response, error) {
 	return response, nil
 }
 
+func (request *Request) send(req *http.Request) (*http.Response, error) {
+	do := request.attempt
+	if policy, ok := request.hedge(); ok && hedgeable(req) {
+		var relative *url.URL
+		if request.endpoint != "" {
+			relative = request.url
+		}
+		do = func(req *http.Request) (*http.Response, error) {
+			return request.client.hedging.do(req, relative, policy, request.attempt, func() {
+				request.retried(RetryHedge)
+			})
+		}
+	}
+
+	if request.client.dedup != nil && request.shareable() {
+		return request.client.dedup.do(req, request.trace, request.maxBodySize(), do)
+	}
+	return do(req)
+}
+
+// shareable reports whether the request may share a deduplicated call. Streams
+// must not wait for the whole body, and requests overriding client policies
+// must not get the behavior of another caller.
+func (request *Request) shareable() bool {
+	return !request.streaming && request.hedgePolicy == nil && request.redirectPolicy == nil && request.maxResponseSize == 0
+}
+
 func (request *Request) Send() (*Response, error) {
+	return request.SendInto(nil)
+}
+
+// SendInto sends the request and decodes a successful response body straight
+// from the connection into v. Such a body is not kept on the Response; with a
+// nil v it behaves like Send.
+func (request *Request) SendInto(v any) (*Response, error) {
 	response, err := request.SendRaw()
+	if err != nil {
+		return nil, err
+	}
+	return request.receive(response, v)
+}
+
+// receive reads the response to the request, decoding a successful body into
+// v.
+func (request *Request) receive(response *http.Response, v any) (*Response, error) {
+	res := &Response{
+		StatusCode:    response.StatusCode,
+		Header:        response.Header,
+		Request:       response.Request,
+		Proto:         response.Proto,
+		ContentLength: response.ContentLength,
+		Endpoint:      request.endpoint,
+		Redirects:     redirectsOf(response),
+		client:        request.client,
+	}
 	if response.Body == nil {
-		return &Response{StatusCode: response.StatusCode}, nil
+		res.finished(request.trace.finish())
+		return request.handleStatus(res)
 	}
 	defer response.Body.Close()
+
+	body, err := request.responseBody(response)
 	if err != nil {
 		return nil, err
 	}
+	defer body.Close()
+
+	success := response.StatusCode >= 200 && response.StatusCode < 300
+	if success && v != nil && !request.client.verbose {
+		err = decodeStream(request.client, response.Header.Get("Content-Type"), body, v)
+		if err != nil {
+			return nil, err
+		}
+		res.finished(request.trace.finish())
+		return request.handleStatus(res)
+	}
 
-	body, err := io.ReadAll(response.Body)
+	data, err := io.ReadAll(body)
 	if err != nil {
 		return nil, err
 	}
+	res.finished(request.trace.finish())
 
 	if request.client.verbose {
-		log.Println(string(body))
+		log.Println(string(data))
+		log.Println("Timings: " + res.Timings.String())
 	}
 
-	res := &Response{StatusCode: response.StatusCode}
-
-	if response.StatusCode >= 200 && response.StatusCode < 300 {
-		res.body = body
-	} else {
-		strBody := string(body)
-		if (strBody[0] == '{' && strBody[len(strBody)-1] == '}') || (strBody[0] == '[' && strBody[len(strBody)-1] == ']') {
-			err = json.Unmarshal(body, &res.Error)
-			if err != nil {
-				return nil, err
-			}
-		} else {
-			res.Error = strBody
+	if success {
+		res.body = data
+		err = res.Decode(v)
+		if err != nil {
+			return nil, err
 		}
+	} else {
+		res.Error = request.decodeError(response.StatusCode, response.Header.Get("Content-Type"), data)
 	}
 
-	return res, nil
+	return request.handleStatus(res)
 }
 
 func (request *Request) SetForm(payload map[string]string) (*Request, error) {
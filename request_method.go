@@ -1,25 +1,15 @@
 package ask
 
-import (
-	"net/http"
-	"os"
-)
+import "net/http"
 
 func GetJson(url string, v any) (*Response, error) {
 	request := NewRequest(http.MethodGet, url)
 	request.setClient(&client)
-	response, err := request.AcceptJson().Send()
+	response, err := request.AcceptJson().SendInto(v)
 	if err != nil {
 		return nil, err
 	}
 
-	if response.body != nil {
-		err = marshalResponseIfStruct(response.body, &v)
-		if err != nil {
-			return nil, err
-		}
-	}
-
 	return response, nil
 }
 
@@ -27,18 +17,11 @@ func PostJson(url string, payload []byte, v any) (*Response, error) {
 	request := NewRequest(http.MethodPost, url)
 	request.setClient(&client)
 	request.WithPayloadJson(payload)
-	response, err := request.AcceptJson().Send()
+	response, err := request.AcceptJson().SendInto(v)
 	if err != nil {
 		return nil, err
 	}
 
-	if response.body != nil {
-		err = marshalResponseIfStruct(response.body, &v)
-		if err != nil {
-			return nil, err
-		}
-	}
-
 	return response, nil
 }
 
@@ -46,18 +29,11 @@ func PutJson(url string, payload []byte, v any) (*Response, error) {
 	request := NewRequest(http.MethodPut, url)
 	request.setClient(&client)
 	request.WithPayloadJson(payload)
-	response, err := request.AcceptJson().Send()
+	response, err := request.AcceptJson().SendInto(v)
 	if err != nil {
 		return nil, err
 	}
 
-	if response.body != nil {
-		err = marshalResponseIfStruct(response.body, &v)
-		if err != nil {
-			return nil, err
-		}
-	}
-
 	return response, nil
 }
 
@@ -65,18 +41,11 @@ func PatchJson(url string, payload []byte, v any) (*Response, error) {
 	request := NewRequest(http.MethodPatch, url)
 	request.setClient(&client)
 	request.WithPayloadJson(payload)
-	response, err := request.AcceptJson().Send()
+	response, err := request.AcceptJson().SendInto(v)
 	if err != nil {
 		return nil, err
 	}
 
-	if response.body != nil {
-		err = marshalResponseIfStruct(response.body, &v)
-		if err != nil {
-			return nil, err
-		}
-	}
-
 	return response, nil
 }
 
@@ -86,30 +55,18 @@ func DeleteJson(url string, payload *[]byte, v any) (*Response, error) {
 	if payload != nil {
 		request.WithPayloadJson(*payload)
 	}
-	response, err := request.AcceptJson().Send()
+	response, err := request.AcceptJson().SendInto(v)
 	if err != nil {
 		return nil, err
 	}
 
-	if response.body != nil && v != nil {
-		err = marshalResponseIfStruct(response.body, &v)
-		if err != nil {
-			return nil, err
-		}
-	}
-
 	return response, nil
 }
 
 func GetFile(url string, dest string) error {
 	request := NewRequest(http.MethodGet, url)
 	request.setClient(&client)
-	response, err := request.Send()
-	if err != nil {
-		return err
-	}
-
-	err = os.WriteFile(dest, response.body, 0644)
+	err := saveToFile(request, dest)
 	if err != nil {
 		return err
 	}
@@ -126,18 +83,11 @@ func PostForm(url string, payload map[string]string, v any) (*Response, error) {
 		return nil, err
 	}
 
-	response, err := request.AcceptJson().Send()
+	response, err := request.AcceptJson().SendInto(v)
 	if err != nil {
 		return nil, err
 	}
 
-	if response.body != nil {
-		err = marshalResponseIfStruct(response.body, &v)
-		if err != nil {
-			return nil, err
-		}
-	}
-
 	return response, nil
 }
 
@@ -150,18 +100,11 @@ func PutForm(url string, payload map[string]string, v any) (*Response, error) {
 		return nil, err
 	}
 
-	response, err := request.AcceptJson().Send()
+	response, err := request.AcceptJson().SendInto(v)
 	if err != nil {
 		return nil, err
 	}
 
-	if response.body != nil {
-		err = marshalResponseIfStruct(response.body, &v)
-		if err != nil {
-			return nil, err
-		}
-	}
-
 	return response, nil
 }
 
@@ -174,17 +117,10 @@ func PatchForm(url string, payload map[string]string, v any) (*Response, error)
 		return nil, err
 	}
 
-	response, err := request.AcceptJson().Send()
+	response, err := request.AcceptJson().SendInto(v)
 	if err != nil {
 		return nil, err
 	}
 
-	if response.body != nil {
-		err = marshalResponseIfStruct(response.body, &v)
-		if err != nil {
-			return nil, err
-		}
-	}
-
 	return response, nil
 }
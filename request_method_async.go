@@ -1,27 +1,16 @@
 package ask
 
-import (
-	"net/http"
-	"os"
-)
+import "net/http"
 
 func GetJsonAsync(url string, v any, res chan Response, error chan error) {
 	request := NewRequest(http.MethodGet, url)
 	request.setClient(&client)
-	response, err := request.AcceptJson().Send()
+	response, err := request.AcceptJson().SendInto(v)
 	if err != nil {
 		error <- err
 		return
 	}
 
-	if response.body != nil {
-		err = marshalResponseIfStruct(response.body, &v)
-		if err != nil {
-			error <- err
-			return
-		}
-	}
-
 	res <- *response
 	error <- nil
 }
@@ -30,20 +19,12 @@ func PostJsonAsync(url string, payload []byte, v any, res chan Response, error c
 	request := NewRequest(http.MethodPost, url)
 	request.setClient(&client)
 	request.WithPayloadJson(payload)
-	response, err := request.AcceptJson().Send()
+	response, err := request.AcceptJson().SendInto(v)
 	if err != nil {
 		error <- err
 		return
 	}
 
-	if response.body != nil {
-		err = marshalResponseIfStruct(response.body, &v)
-		if err != nil {
-			error <- err
-			return
-		}
-	}
-
 	res <- *response
 	error <- nil
 }
@@ -52,20 +33,12 @@ func PutJsonAsync(url string, payload []byte, v any, res chan Response, error ch
 	request := NewRequest(http.MethodPut, url)
 	request.setClient(&client)
 	request.WithPayloadJson(payload)
-	response, err := request.AcceptJson().Send()
+	response, err := request.AcceptJson().SendInto(v)
 	if err != nil {
 		error <- err
 		return
 	}
 
-	if response.body != nil {
-		err = marshalResponseIfStruct(response.body, &v)
-		if err != nil {
-			error <- err
-			return
-		}
-	}
-
 	res <- *response
 	error <- nil
 }
@@ -74,20 +47,12 @@ func PatchJsonAsync(url string, payload []byte, v any, res chan Response, error
 	request := NewRequest(http.MethodPatch, url)
 	request.setClient(&client)
 	request.WithPayloadJson(payload)
-	response, err := request.AcceptJson().Send()
+	response, err := request.AcceptJson().SendInto(v)
 	if err != nil {
 		error <- err
 		return
 	}
 
-	if response.body != nil {
-		err = marshalResponseIfStruct(response.body, &v)
-		if err != nil {
-			error <- err
-			return
-		}
-	}
-
 	res <- *response
 	error <- nil
 }
@@ -98,20 +63,12 @@ func DeleteJsonAsync(url string, payload *[]byte, v any, res chan Response, erro
 	if payload != nil {
 		request.WithPayloadJson(*payload)
 	}
-	response, err := request.AcceptJson().Send()
+	response, err := request.AcceptJson().SendInto(v)
 	if err != nil {
 		error <- err
 		return
 	}
 
-	if response.body != nil && v != nil {
-		err = marshalResponseIfStruct(response.body, &v)
-		if err != nil {
-			error <- err
-			return
-		}
-	}
-
 	res <- *response
 	error <- nil
 }
@@ -119,13 +76,7 @@ func DeleteJsonAsync(url string, payload *[]byte, v any, res chan Response, erro
 func GetFileAsync(url string, dest string, error chan error) {
 	request := NewRequest(http.MethodGet, url)
 	request.setClient(&client)
-	response, err := request.Send()
-	if err != nil {
-		error <- err
-		return
-	}
-
-	err = os.WriteFile(dest, response.body, 0644)
+	err := saveToFile(request, dest)
 	if err != nil {
 		error <- err
 		return
@@ -0,0 +1,96 @@
+package ask
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync/atomic"
+)
+
+// resolver sends connections for some host:port pairs to fixed addresses,
+// like curl --resolve.
+type resolver struct {
+	hosts    map[string]*resolvedHost
+	fallback bool
+}
+
+type resolvedHost struct {
+	addresses []string
+	next      atomic.Uint32
+}
+
+// WithResolve dials addresses instead of resolving hostPort, e.g.
+// "api.example.com:443". The Host header and TLS server name still come from
+// the URL. Addresses without a port use the port of hostPort. With several
+// addresses, connections are spread round-robin and a failing address is
+// skipped.
+func WithResolve(hostPort string, addresses ...string) ClientOption {
+	return func(config *transportConfig) {
+		host, port, err := net.SplitHostPort(hostPort)
+		if err != nil {
+			config.fail(fmt.Errorf("ask: invalid resolve host %q: %w", hostPort, err))
+			return
+		}
+		if len(addresses) == 0 {
+			config.fail(fmt.Errorf("ask: no address to resolve %s to", hostPort))
+			return
+		}
+
+		resolved := &resolvedHost{}
+		for _, address := range addresses {
+			if _, _, err := net.SplitHostPort(address); err != nil {
+				address = net.JoinHostPort(address, port)
+			}
+			resolved.addresses = append(resolved.addresses, address)
+		}
+		config.resolver().hosts[net.JoinHostPort(strings.ToLower(host), port)] = resolved
+	}
+}
+
+// WithResolveFallback resolves the host with DNS when none of the addresses
+// given to WithResolve can be dialed.
+func WithResolveFallback() ClientOption {
+	return func(config *transportConfig) {
+		config.resolver().fallback = true
+	}
+}
+
+func (config *transportConfig) resolver() *resolver {
+	if config.resolve == nil {
+		config.resolve = &resolver{hosts: map[string]*resolvedHost{}}
+	}
+	return config.resolve
+}
+
+func (resolver *resolver) dial(dial DialFunc) DialFunc {
+	return func(ctx context.Context, network string, address string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			return dial(ctx, network, address)
+		}
+		resolved, ok := resolver.hosts[net.JoinHostPort(strings.ToLower(host), port)]
+		if !ok {
+			return dial(ctx, network, address)
+		}
+
+		var errs []error
+		start := int(resolved.next.Add(1) - 1)
+		for i := range resolved.addresses {
+			conn, err := dial(ctx, network, resolved.addresses[(start+i)%len(resolved.addresses)])
+			if err == nil {
+				return conn, nil
+			}
+			errs = append(errs, err)
+			if ctx.Err() != nil {
+				break
+			}
+		}
+
+		if resolver.fallback && ctx.Err() == nil {
+			return dial(ctx, network, address)
+		}
+		return nil, errors.Join(errs...)
+	}
+}
@@ -0,0 +1,84 @@
+package ask
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func hostServer(t *testing.T, name string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(name + " " + r.Host))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestResolveOverride(t *testing.T) {
+	blue, green := hostServer(t, "blue"), hostServer(t, "green")
+	_, bluePort, _ := net.SplitHostPort(blue.Listener.Addr().String())
+
+	c := NewClient(context.Background(),
+		WithResolve("api.example.com:80", blue.Listener.Addr().String(), green.Listener.Addr().String()),
+		WithoutKeepAlives(),
+	)
+	var bodies []string
+	for i := 0; i < 4; i++ {
+		res, err := c.NewRequest(http.MethodGet, "http://api.example.com/").Send()
+		if err != nil {
+			t.Fatal(err)
+		}
+		bodies = append(bodies, string(*res.GetBody()))
+	}
+	assert.Equal(t, []string{"blue api.example.com", "green api.example.com", "blue api.example.com", "green api.example.com"}, bodies)
+
+	// An address without port uses the port of the host, and dead addresses are skipped.
+	c = NewClient(context.Background(), WithResolve("api.example.com:"+bluePort, "127.0.0.1:1", "127.0.0.1"))
+	for i := 0; i < 2; i++ {
+		res, err := c.NewRequest(http.MethodGet, "http://api.example.com:"+bluePort+"/").Send()
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "blue api.example.com:"+bluePort, string(*res.GetBody()))
+	}
+}
+
+func TestResolveFallback(t *testing.T) {
+	server := hostServer(t, "dns")
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	url := "http://localhost:" + port + "/"
+
+	_, err := NewClient(context.Background(), WithResolve("localhost:"+port, "127.0.0.1:1")).NewRequest(http.MethodGet, url).Send()
+	assert.Error(t, err)
+
+	res, err := NewClient(context.Background(), WithResolve("localhost:"+port, "127.0.0.1:1"), WithResolveFallback()).NewRequest(http.MethodGet, url).Send()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "dns localhost:"+port, string(*res.GetBody()))
+	}
+
+	_, err = NewClient(context.Background(), WithResolve("localhost", "127.0.0.1")).NewRequest(http.MethodGet, url).Send()
+	assert.ErrorContains(t, err, "invalid resolve host")
+}
+
+func TestResolveKeepsServerName(t *testing.T) {
+	ca := newTestCa(t)
+	certPem, keyPem := ca.issue(t, "api.example.com")
+	cert, _ := tls.X509KeyPair(certPem, keyPem)
+	var serverName string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serverName = r.TLS.ServerName
+	}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	server.StartTLS()
+	defer server.Close()
+
+	c := NewClient(context.Background(), WithCaPem(ca.pem), WithResolve("api.example.com:443", server.Listener.Addr().String()))
+	_, err := c.NewRequest(http.MethodGet, "https://api.example.com/").Send()
+	assert.NoError(t, err)
+	assert.Equal(t, "api.example.com", serverName)
+}
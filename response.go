@@ -1,11 +1,39 @@
 package ask
 
+import (
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
 type Response struct {
 	body       []byte
+	client     *Client
 	StatusCode int
-	Error      interface{}
+	Header     http.Header
+	// Request is the request that got the response, after redirects and
+	// failover: its method and final URL. Its body has been sent already.
+	Request *http.Request
+	// Proto is the protocol of the response, e.g. "HTTP/2.0".
+	Proto string
+	// ContentLength is the length of the body announced by the server, -1
+	// when unknown.
+	ContentLength int64
+	// Duration is the time from sending the request to reading the body.
+	Duration time.Duration
+	Error    interface{}
+	Timings  Timings
+	// Endpoint is the base URL the request was sent to, empty for absolute
+	// request URLs.
+	Endpoint string
+	// Redirects are the redirects followed to get the response.
+	Redirects []Redirect
 }
 
+// GetBody returns the body, nil when it was decoded straight into a value by
+// SendInto or the helpers like GetJson.
 func (response Response) GetBody() *[]byte {
 	if len(response.body) == 0 {
 		return nil
@@ -13,3 +41,77 @@ func (response Response) GetBody() *[]byte {
 
 	return &response.body
 }
+
+// Bytes returns the body, nil when it was decoded straight into a value by
+// SendInto.
+func (response Response) Bytes() []byte {
+	return response.body
+}
+
+// Text returns the body as a string.
+func (response Response) Text() string {
+	return string(response.body)
+}
+
+// Decode decodes the body with the codec matching the response Content-Type.
+func (response Response) Decode(v any) error {
+	return decodeBody(response.client, response.Header.Get("Content-Type"), response.body, v)
+}
+
+// IsSuccess reports whether the status code is 2xx.
+func (response Response) IsSuccess() bool {
+	return response.StatusCode >= 200 && response.StatusCode < 300
+}
+
+// Location returns the Location header resolved against the request URL, or
+// http.ErrNoLocation.
+func (response Response) Location() (*url.URL, error) {
+	location := response.Header.Get("Location")
+	if location == "" {
+		return nil, http.ErrNoLocation
+	}
+	if response.Request != nil && response.Request.URL != nil {
+		return response.Request.URL.Parse(location)
+	}
+	return url.Parse(location)
+}
+
+// ContentType returns the media type of the body, without parameters.
+func (response Response) ContentType() string {
+	mediaType, _, err := mime.ParseMediaType(response.Header.Get("Content-Type"))
+	if err != nil {
+		return ""
+	}
+	return mediaType
+}
+
+// HeaderInt returns the header key as an integer, false when missing or
+// invalid.
+func (response Response) HeaderInt(key string) (int64, bool) {
+	n, err := strconv.ParseInt(response.Header.Get(key), 10, 64)
+	return n, err == nil
+}
+
+// HeaderTime returns the header key as an HTTP date, false when missing or
+// invalid.
+func (response Response) HeaderTime(key string) (time.Time, bool) {
+	t, err := http.ParseTime(response.Header.Get(key))
+	return t, err == nil
+}
+
+// RetryAfter returns how long the Retry-After header asks to wait, given in
+// seconds or as a date. It is false without the header.
+func (response Response) RetryAfter() (time.Duration, bool) {
+	if seconds, ok := response.HeaderInt("Retry-After"); ok && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, ok := response.HeaderTime("Retry-After"); ok {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
+
+func (response *Response) finished(timings Timings) {
+	response.Timings = timings
+	response.Duration = timings.Total
+}
@@ -0,0 +1,78 @@
+package ask
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseDetails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, "/new", http.StatusFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set("X-Total-Count", "42")
+		w.Header().Set("Last-Modified", "Mon, 19 Oct 2026 10:00:00 GMT")
+		_, _ = w.Write([]byte(`{"title":"post"}`))
+	}))
+	defer server.Close()
+
+	request := NewRequest(http.MethodGet, server.URL+"/old")
+	request.setClient(NewClient(context.Background()))
+	res, err := request.Send()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.True(t, res.IsSuccess())
+	assert.Equal(t, http.MethodGet, res.Request.Method)
+	assert.Equal(t, server.URL+"/new", res.Request.URL.String())
+	assert.Equal(t, "HTTP/1.1", res.Proto)
+	assert.Equal(t, int64(16), res.ContentLength)
+	assert.Equal(t, res.Timings.Total, res.Duration)
+	assert.Equal(t, `{"title":"post"}`, res.Text())
+	assert.Equal(t, []byte(`{"title":"post"}`), res.Bytes())
+	assert.Equal(t, "application/json", res.ContentType())
+
+	total, ok := res.HeaderInt("X-Total-Count")
+	assert.True(t, ok)
+	assert.Equal(t, int64(42), total)
+	modified, ok := res.HeaderTime("Last-Modified")
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC), modified)
+	_, ok = res.HeaderInt("X-Missing")
+	assert.False(t, ok)
+
+	var post Post
+	assert.NoError(t, res.Decode(&post))
+	assert.Equal(t, "post", post.Title)
+}
+
+func TestResponseLocationAndRetryAfter(t *testing.T) {
+	res := Response{Header: http.Header{}, Request: httptest.NewRequest(http.MethodGet, "https://example.com/a/b", nil)}
+	_, err := res.Location()
+	assert.ErrorIs(t, err, http.ErrNoLocation)
+	_, ok := res.RetryAfter()
+	assert.False(t, ok)
+
+	res.Header.Set("Location", "../c")
+	location, err := res.Location()
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/c", location.String())
+
+	res.Header.Set("Retry-After", "120")
+	wait, ok := res.RetryAfter()
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Minute, wait)
+
+	res.Header.Set("Retry-After", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat))
+	wait, ok = res.RetryAfter()
+	assert.True(t, ok)
+	assert.Zero(t, wait)
+}
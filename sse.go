@@ -0,0 +1,295 @@
+package ask
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const maxEventLineSize = 1 << 20
+
+// Event is a single Server-Sent Event.
+type Event struct {
+	Id    string
+	Event string
+	Data  string
+	Retry time.Duration
+}
+
+// Decode decodes the event data as JSON.
+func (event Event) Decode(v any) error {
+	return JsonCodec{}.Unmarshal([]byte(event.Data), v)
+}
+
+// EventSource reads Server-Sent Events from a request and reconnects with the
+// Last-Event-ID header whenever the stream drops.
+type EventSource struct {
+	request *Request
+
+	// LastEventId is sent on (re)connection and tracks the last id received.
+	LastEventId string
+	// Retry is the reconnection delay, updated by the server retry field.
+	Retry time.Duration
+	// MaxRetry caps the exponential backoff applied to consecutive failures.
+	MaxRetry time.Duration
+	// MaxReconnects gives up after that many consecutive failed attempts.
+	// Zero retries forever.
+	MaxReconnects int
+}
+
+// minEventBackoff is the least delay between consecutive failed attempts, even
+// when the server asked to retry immediately.
+const minEventBackoff = 100 * time.Millisecond
+
+func (request *Request) EventSource() *EventSource {
+	return &EventSource{
+		request:  request,
+		Retry:    3 * time.Second,
+		MaxRetry: time.Minute,
+	}
+}
+
+// Events connects to the stream and yields events until the context of the
+// request is cancelled, the server answers 204 No Content, or the connection
+// fails permanently. Failed connections are yielded as errors; the stream
+// reconnects after those that may be temporary, unless the consumer stops.
+func (source *EventSource) Events() iter.Seq2[Event, error] {
+	return func(yield func(Event, error) bool) {
+		ctx := source.request.context()
+		failures := 0
+
+		for {
+			received, err := source.connect(ctx, yield)
+			if ctx.Err() != nil || err == errStopEvents {
+				return
+			}
+
+			if received && err == nil {
+				failures = 0
+			}
+			failures++
+			if source.MaxReconnects > 0 && failures > source.MaxReconnects {
+				if err == nil {
+					err = io.ErrUnexpectedEOF
+				}
+				yield(Event{}, fmt.Errorf("ask: event stream gave up after %d reconnects: %w", source.MaxReconnects, err))
+				return
+			}
+
+			timer := time.NewTimer(source.backoff(failures))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+			source.request.retried(RetryReconnect)
+		}
+	}
+}
+
+var errStopEvents = errors.New("ask: event stream stopped")
+
+// permanentError reports whether err would fail every reconnection too.
+func permanentError(err error) bool {
+	var ssrfErr *SsrfError
+	var pinErr *PinError
+	var urlErr *url.Error
+	return errors.As(err, &ssrfErr) || errors.As(err, &pinErr) || errors.Is(err, ErrTooManyRedirects) ||
+		(errors.As(err, &urlErr) && urlErr.Op == "parse")
+}
+
+func retryableStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= 500
+}
+
+func (source *EventSource) backoff(failures int) time.Duration {
+	delay := source.Retry
+	if failures > 1 && delay < minEventBackoff {
+		delay = minEventBackoff
+	}
+	for i := 1; i < failures && delay < source.MaxRetry; i++ {
+		delay *= 2
+	}
+	if source.MaxRetry > 0 && delay > source.MaxRetry {
+		delay = source.MaxRetry
+	}
+	return delay
+}
+
+// connect opens one connection and dispatches its events. It reports whether
+// any event was received, and returns errStopEvents when the consumer or the
+// server ended the stream.
+func (source *EventSource) connect(ctx context.Context, yield func(Event, error) bool) (bool, error) {
+	request := source.request
+	request.streaming = true
+	request.Header.Set("Accept", "text/event-stream")
+	request.Header.Set("Cache-Control", "no-cache")
+	if source.LastEventId != "" {
+		request.Header.Set("Last-Event-ID", source.LastEventId)
+	} else {
+		request.Header.Del("Last-Event-ID")
+	}
+
+	response, err := request.WithContext(ctx).SendRaw()
+	if err != nil {
+		if ctx.Err() != nil {
+			return false, err
+		}
+		if !yield(Event{}, err) || permanentError(err) {
+			return false, errStopEvents
+		}
+		return false, err
+	}
+	if response.Body == nil {
+		response.Body = http.NoBody
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNoContent {
+		return false, errStopEvents
+	}
+	if response.StatusCode != http.StatusOK {
+		err = request.statusError(response)
+		if !retryableStatus(response.StatusCode) {
+			yield(Event{}, err)
+			return false, errStopEvents
+		}
+		return false, err
+	}
+
+	// The stream is long-lived, so the size limit applies to lines rather
+	// than to the whole body.
+	body, err := decodedBody(response, 0)
+	if err != nil {
+		return false, err
+	}
+	defer body.Close()
+
+	maxLine := maxEventLineSize
+	if limit := request.maxBodySize(); limit > 0 && limit < int64(maxLine) {
+		maxLine = int(limit)
+	}
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, min(4096, maxLine)), maxLine)
+	scanner.Split(scanEventLines)
+
+	received := false
+	event := Event{}
+	var data strings.Builder
+	first := true
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if first {
+			line = strings.TrimPrefix(line, "\ufeff")
+			first = false
+		}
+
+		if line == "" {
+			if data.Len() == 0 {
+				event = Event{}
+				continue
+			}
+			event.Id = source.LastEventId
+			event.Data = strings.TrimSuffix(data.String(), "\n")
+			if event.Event == "" {
+				event.Event = "message"
+			}
+			received = true
+			if !yield(event, nil) {
+				return received, errStopEvents
+			}
+			event = Event{}
+			data.Reset()
+			continue
+		}
+		if line[0] == ':' {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event.Event = value
+		case "data":
+			data.WriteString(value)
+			data.WriteByte('\n')
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				source.LastEventId = value
+			}
+		case "retry":
+			ms, err := strconv.ParseUint(value, 10, 63)
+			if err == nil {
+				source.Retry = time.Duration(ms) * time.Millisecond
+				event.Retry = source.Retry
+			}
+		}
+	}
+
+	if err := scanner.Err(); errors.Is(err, bufio.ErrTooLong) {
+		// The same line would be sent again on reconnection.
+		yield(Event{}, fmt.Errorf("ask: event stream line longer than %d bytes: %w", maxLine, err))
+		return received, errStopEvents
+	}
+	return received, scanner.Err()
+}
+
+// scanEventLines splits on CRLF, LF or a lone CR, as the event stream format
+// allows all three.
+func scanEventLines(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\n' {
+			return i + 1, data[:i], nil
+		}
+		if i+1 < len(data) {
+			if data[i+1] == '\n' {
+				return i + 2, data[:i], nil
+			}
+			return i + 1, data[:i], nil
+		}
+		if atEOF {
+			return i + 1, data[:i], nil
+		}
+		// A trailing CR may be the first half of a CRLF.
+		return 0, nil, nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// SubscribeJson streams the events of url with the global client and decodes
+// their data as JSON into T.
+func SubscribeJson[T any](ctx context.Context, url string) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		request := NewRequest(http.MethodGet, url)
+		request.setClient(&client)
+
+		for event, err := range request.WithContext(ctx).EventSource().Events() {
+			var v T
+			if err == nil {
+				err = event.Decode(&v)
+			}
+			if !yield(v, err) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package ask
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventSourceReconnects(t *testing.T) {
+	var connections atomic.Int32
+	var lastEventIds []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := connections.Add(1)
+		lastEventIds = append(lastEventIds, r.Header.Get("Last-Event-ID"))
+		w.Header().Set("Content-Type", "text/event-stream")
+
+		switch n {
+		case 1:
+			fmt.Fprint(w, "\ufeff: comment\r\nretry: 10\r\nid: 1\r\nevent: post\r\ndata: {\"title\":\"first\",\r\ndata: \"id\":1}\r\n\r\n")
+			fmt.Fprint(w, "id: 2\ndata: second\n\ndata: incomplete")
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 3:
+			fmt.Fprint(w, "data:third\rid\r\r")
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	request := NewRequest(http.MethodGet, server.URL)
+	source := request.EventSource()
+
+	var events []Event
+	for event, err := range source.Events() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, event)
+	}
+
+	assert.Len(t, events, 3)
+	assert.Equal(t, Event{Id: "1", Event: "post", Data: "{\"title\":\"first\",\n\"id\":1}", Retry: 10 * time.Millisecond}, events[0])
+	assert.Equal(t, Event{Id: "2", Event: "message", Data: "second"}, events[1])
+	assert.Equal(t, Event{Id: "", Event: "message", Data: "third"}, events[2])
+	assert.Equal(t, []string{"", "2", "2", ""}, lastEventIds)
+
+	var post Post
+	assert.NoError(t, events[0].Decode(&post))
+	assert.Equal(t, "first", post.Title)
+}
+
+func TestEventSourceFailsOnClientError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	var err error
+	for _, err = range NewRequest(http.MethodGet, server.URL).EventSource().Events() {
+	}
+
+	var statusErr *StatusError
+	assert.True(t, errors.As(err, &statusErr))
+	assert.Equal(t, http.StatusForbidden, statusErr.StatusCode)
+}
+
+func TestSubscribeJsonStopsOnCancel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := 1; ; i++ {
+			_, err := fmt.Fprintf(w, "data: {\"id\":%d}\n\n", i)
+			if err != nil {
+				return
+			}
+			w.(http.Flusher).Flush()
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(5 * time.Millisecond):
+			}
+		}
+	}))
+	defer server.Close()
+
+	useClient(t, NewClient(context.Background()))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var ids []int
+	for post, err := range SubscribeJson[Post](ctx, server.URL) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, post.Id)
+		if len(ids) == 3 {
+			cancel()
+		}
+	}
+
+	assert.Equal(t, []int{1, 2, 3}, ids)
+}
+
+func TestEventSourceLineTooLong(t *testing.T) {
+	var connections atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		connections.Add(1)
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: first\n\ndata: "+strings.Repeat("a", 128)+"\n\n")
+	}))
+	defer server.Close()
+
+	c := NewClient(context.Background())
+	c.SetMaxResponseSize(64)
+	request := c.NewRequest(http.MethodGet, server.URL)
+
+	var events []Event
+	var errs []error
+	for event, err := range request.EventSource().Events() {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		events = append(events, event)
+	}
+
+	assert.Len(t, events, 1)
+	assert.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], bufio.ErrTooLong)
+	assert.Equal(t, int32(1), connections.Load())
+}
+
+func TestEventSourceNilBody(t *testing.T) {
+	c := mockClient(nil)
+	request := c.NewRequest(http.MethodDelete, "/posts/1")
+
+	for _, err := range request.EventSource().Events() {
+		t.Fatal(err)
+	}
+}
+
+func TestEventSourceBackoff(t *testing.T) {
+	source := NewRequest(http.MethodGet, "/").EventSource()
+	source.Retry = 0
+	assert.Equal(t, time.Duration(0), source.backoff(1))
+	assert.Equal(t, 2*minEventBackoff, source.backoff(2))
+	assert.Equal(t, 4*minEventBackoff, source.backoff(3))
+}
+
+func TestEventSourceUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	serverUrl := server.URL
+	server.Close()
+
+	source := NewRequest(http.MethodGet, serverUrl).EventSource()
+	source.Retry = time.Millisecond
+	source.MaxReconnects = 2
+	var errs []error
+	for _, err := range source.Events() {
+		errs = append(errs, err)
+	}
+	assert.Len(t, errs, 4)
+	assert.ErrorContains(t, errs[3], "gave up after 2 reconnects")
+
+	// Blocked requests are not retried.
+	c := NewClient(context.Background(), WithSsrfGuard(SsrfPolicy{}))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	errs = nil
+	for _, err := range c.NewRequest(http.MethodGet, serverUrl).WithContext(ctx).EventSource().Events() {
+		errs = append(errs, err)
+	}
+	assert.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], ErrSsrfBlocked)
+	assert.NoError(t, ctx.Err())
+}
@@ -0,0 +1,218 @@
+package ask
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+)
+
+var ErrSsrfBlocked = errors.New("ask: request blocked by SSRF guard")
+
+// SsrfError is returned when the SSRF guard blocks a request, before any byte
+// is sent.
+type SsrfError struct {
+	Host string
+	// Address is the IP address dialed, empty when the request was blocked
+	// for its URL.
+	Address string
+	Reason  string
+}
+
+func (err *SsrfError) Error() string {
+	if err.Address != "" {
+		return fmt.Sprintf("ask: request to %s (%s) blocked: %s", err.Host, err.Address, err.Reason)
+	}
+	return fmt.Sprintf("ask: request to %s blocked: %s", err.Host, err.Reason)
+}
+
+func (err *SsrfError) Is(target error) bool {
+	return target == ErrSsrfBlocked
+}
+
+// SsrfPolicy configures the SSRF guard. Hosts are names like "example.com",
+// or "*.example.com" for its subdomains; networks are CIDRs like
+// "10.0.0.0/8".
+type SsrfPolicy struct {
+	// AllowHosts, when not empty, is the only hosts requests may go to.
+	AllowHosts []string
+	DenyHosts  []string
+	// AllowNetworks are exempted from the blocking of private, loopback,
+	// link-local and multicast addresses.
+	AllowNetworks []string
+	DenyNetworks  []string
+	// Schemes defaults to http and https.
+	Schemes []string
+}
+
+// sharedAddressSpace is the carrier-grade NAT range, where some clouds serve
+// instance metadata.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// translatedNetworks embed an IPv4 address that could reach private networks:
+// NAT64 prefixes, and IPv4-compatible addresses that Unmap does not convert.
+var translatedNetworks = map[netip.Prefix]string{
+	netip.MustParsePrefix("64:ff9b::/96"):   "NAT64 address",
+	netip.MustParsePrefix("64:ff9b:1::/48"): "NAT64 address",
+	netip.MustParsePrefix("::/96"):          "IPv4-compatible address",
+}
+
+type ssrfGuard struct {
+	allowHosts    []string
+	denyHosts     []string
+	allowNetworks []netip.Prefix
+	denyNetworks  []netip.Prefix
+	schemes       []string
+}
+
+// WithSsrfGuard protects against server-side request forgery, for clients
+// calling URLs chosen by users such as webhooks. It blocks loopback,
+// link-local, private, multicast and unspecified addresses, checking the
+// address actually dialed so DNS rebinding cannot get around it, and applies
+// the host, network and scheme lists of policy. Proxies are disabled, since
+// they would resolve hosts out of reach of the guard.
+func WithSsrfGuard(policy SsrfPolicy) ClientOption {
+	return func(config *transportConfig) {
+		guard := &ssrfGuard{schemes: []string{"http", "https"}}
+		for _, host := range policy.AllowHosts {
+			guard.allowHosts = append(guard.allowHosts, normalizeHost(host))
+		}
+		for _, host := range policy.DenyHosts {
+			guard.denyHosts = append(guard.denyHosts, normalizeHost(host))
+		}
+		for _, network := range policy.AllowNetworks {
+			prefix, err := netip.ParsePrefix(network)
+			if err != nil {
+				config.fail(fmt.Errorf("ask: invalid network %q: %w", network, err))
+				return
+			}
+			guard.allowNetworks = append(guard.allowNetworks, prefix)
+		}
+		for _, network := range policy.DenyNetworks {
+			prefix, err := netip.ParsePrefix(network)
+			if err != nil {
+				config.fail(fmt.Errorf("ask: invalid network %q: %w", network, err))
+				return
+			}
+			guard.denyNetworks = append(guard.denyNetworks, prefix)
+		}
+		if len(policy.Schemes) > 0 {
+			guard.schemes = nil
+			for _, scheme := range policy.Schemes {
+				guard.schemes = append(guard.schemes, strings.ToLower(scheme))
+			}
+		}
+		config.guard = guard
+	}
+}
+
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+func matchHost(patterns []string, host string) bool {
+	for _, pattern := range patterns {
+		if pattern == host || (strings.HasPrefix(pattern, "*.") && strings.HasSuffix(host, pattern[1:])) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkUrl applies the scheme and host lists, and the address rules to IP
+// literals, before anything is dialed.
+func (guard *ssrfGuard) checkUrl(req *http.Request) error {
+	host := normalizeHost(req.URL.Hostname())
+	scheme := strings.ToLower(req.URL.Scheme)
+	allowedScheme := false
+	for _, s := range guard.schemes {
+		allowedScheme = allowedScheme || s == scheme
+	}
+	if !allowedScheme {
+		return &SsrfError{Host: host, Reason: "scheme " + scheme + " not allowed"}
+	}
+	if matchHost(guard.denyHosts, host) {
+		return &SsrfError{Host: host, Reason: "host denied"}
+	}
+	if len(guard.allowHosts) > 0 && !matchHost(guard.allowHosts, host) {
+		return &SsrfError{Host: host, Reason: "host not allowed"}
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if reason := guard.checkAddr(addr); reason != "" {
+			return &SsrfError{Host: host, Address: addr.String(), Reason: reason}
+		}
+	}
+	return nil
+}
+
+// checkAddr returns why addr is blocked, or an empty string.
+func (guard *ssrfGuard) checkAddr(addr netip.Addr) string {
+	addr = addr.Unmap()
+	for _, prefix := range guard.denyNetworks {
+		if prefix.Contains(addr) {
+			return "network " + prefix.String() + " denied"
+		}
+	}
+	for _, prefix := range guard.allowNetworks {
+		if prefix.Contains(addr) {
+			return ""
+		}
+	}
+
+	switch {
+	case addr.IsLoopback():
+		return "loopback address"
+	case addr.IsLinkLocalUnicast(), addr.IsLinkLocalMulticast():
+		return "link-local address"
+	case addr.IsPrivate(), sharedAddressSpace.Contains(addr):
+		return "private address"
+	case addr.IsMulticast(), addr.IsInterfaceLocalMulticast():
+		return "multicast address"
+	case addr.IsUnspecified(), addr.Is4() && addr.As4()[0] == 0:
+		return "unspecified address"
+	}
+	for prefix, reason := range translatedNetworks {
+		if prefix.Contains(addr) {
+			return reason
+		}
+	}
+	return ""
+}
+
+// control runs once the address to dial is resolved, right before connecting.
+func (guard *ssrfGuard) control(network string, address string, _ syscall.RawConn) error {
+	if !strings.HasPrefix(network, "tcp") && !strings.HasPrefix(network, "udp") {
+		return &SsrfError{Host: address, Reason: "network " + network + " not allowed"}
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return &SsrfError{Host: host, Reason: "unresolved address"}
+	}
+	if reason := guard.checkAddr(addr); reason != "" {
+		return &SsrfError{Host: host, Address: addr.String(), Reason: reason}
+	}
+	return nil
+}
+
+// guardTransport checks every request, redirects included, before sending it.
+type guardTransport struct {
+	guard *ssrfGuard
+	inner http.RoundTripper
+}
+
+func (transport *guardTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := transport.guard.checkUrl(req); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	return transport.inner.RoundTrip(req)
+}
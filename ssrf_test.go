@@ -0,0 +1,81 @@
+package ask
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSsrfGuardAddresses(t *testing.T) {
+	guard := &ssrfGuard{allowNetworks: []netip.Prefix{netip.MustParsePrefix("10.1.0.0/16")}, denyNetworks: []netip.Prefix{netip.MustParsePrefix("203.0.113.0/24")}}
+	for addr, reason := range map[string]string{
+		"169.254.169.254":    "link-local address",
+		"127.0.0.1":          "loopback address",
+		"::1":                "loopback address",
+		"::ffff:127.0.0.1":   "loopback address",
+		"10.0.0.1":           "private address",
+		"172.16.5.4":         "private address",
+		"192.168.1.1":        "private address",
+		"fd00::1":            "private address",
+		"100.100.100.200":    "private address",
+		"239.1.2.3":          "multicast address",
+		"0.0.0.0":            "unspecified address",
+		"64:ff9b::a9fe:a9fe": "NAT64 address",
+		"64:ff9b:1::a00:1":   "NAT64 address",
+		"::a9fe:a9fe":        "IPv4-compatible address",
+		"203.0.113.7":        "network 203.0.113.0/24 denied",
+		"10.1.2.3":           "",
+		"93.184.216.34":      "",
+		"2606:4700::1111":    "",
+	} {
+		assert.Equal(t, reason, guard.checkAddr(netip.MustParseAddr(addr)), addr)
+	}
+}
+
+func TestSsrfGuard(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "http://169.254.169.254/latest/meta-data/", http.StatusFound)
+		}
+	}))
+	defer server.Close()
+	port := server.URL[strings.LastIndex(server.URL, ":"):]
+
+	send := func(policy SsrfPolicy, url string) error {
+		c := NewClient(context.Background(), WithSsrfGuard(policy))
+		_, err := c.NewRequest(http.MethodGet, url).Send()
+		return err
+	}
+	blocked := func(err error, reason string) {
+		t.Helper()
+		assert.ErrorIs(t, err, ErrSsrfBlocked)
+		var ssrfErr *SsrfError
+		if assert.True(t, errors.As(err, &ssrfErr)) {
+			assert.Equal(t, reason, ssrfErr.Reason)
+		}
+	}
+
+	blocked(send(SsrfPolicy{}, server.URL), "loopback address")
+	// A name resolving to a blocked address is caught when dialing.
+	blocked(send(SsrfPolicy{}, "http://localhost"+port), "loopback address")
+	blocked(send(SsrfPolicy{}, "ftp://example.com/file"), "scheme ftp not allowed")
+	blocked(send(SsrfPolicy{DenyHosts: []string{"*.internal"}}, "http://billing.internal/"), "host denied")
+	blocked(send(SsrfPolicy{AllowHosts: []string{"hooks.example.com"}}, "http://example.org/"), "host not allowed")
+	assert.Equal(t, int32(0), hits.Load())
+
+	allowLocal := SsrfPolicy{AllowNetworks: []string{"127.0.0.0/8", "::1/128"}}
+	assert.NoError(t, send(allowLocal, server.URL))
+	assert.NoError(t, send(allowLocal, "http://localhost"+port))
+	blocked(send(allowLocal, server.URL+"/redirect"), "link-local address")
+
+	assert.ErrorContains(t, send(SsrfPolicy{AllowNetworks: []string{"nope"}}, server.URL), "invalid network")
+}
@@ -0,0 +1,206 @@
+package ask
+
+import (
+	"net/http"
+	"slices"
+)
+
+// StatusClass is the class of a status code, its first digit.
+type StatusClass int
+
+const (
+	Status1xx StatusClass = iota + 1
+	Status2xx
+	Status3xx
+	Status4xx
+	Status5xx
+)
+
+// StatusHandler is called with a response once its body is read. The error
+// it returns, if any, is returned by Send instead of the response.
+type StatusHandler func(response *Response) error
+
+// statusRules are the status handlers, expectations and error types of a
+// client or a request.
+type statusRules struct {
+	handlers      map[int]StatusHandler
+	classHandlers map[StatusClass]StatusHandler
+	expected      []int
+	types         map[int]func() any
+	errorType     func() error
+}
+
+func (rules *statusRules) onStatus(statusCode int, handler StatusHandler) {
+	if rules.handlers == nil {
+		rules.handlers = map[int]StatusHandler{}
+	}
+	rules.handlers[statusCode] = handler
+}
+
+func (rules *statusRules) onStatusClass(class StatusClass, handler StatusHandler) {
+	if rules.classHandlers == nil {
+		rules.classHandlers = map[StatusClass]StatusHandler{}
+	}
+	rules.classHandlers[class] = handler
+}
+
+func (rules *statusRules) decodeStatus(statusCode int, newBody func() any) {
+	if rules.types == nil {
+		rules.types = map[int]func() any{}
+	}
+	rules.types[statusCode] = newBody
+}
+
+// OnStatus calls handler for responses with statusCode, e.g. to turn a 404
+// into a domain error. It takes precedence over OnStatusClass.
+func (client *Client) OnStatus(statusCode int, handler StatusHandler) Client {
+	client.status.onStatus(statusCode, handler)
+	return *client
+}
+
+// OnStatusClass calls handler for responses whose status is in class, e.g.
+// Status5xx.
+func (client *Client) OnStatusClass(class StatusClass, handler StatusHandler) Client {
+	client.status.onStatusClass(class, handler)
+	return *client
+}
+
+// Expect fails responses with any other status code than statusCodes with a
+// *StatusError. Statuses with a handler are expected.
+func (client *Client) Expect(statusCodes ...int) Client {
+	client.status.expected = statusCodes
+	return *client
+}
+
+// DecodeStatus decodes error bodies with statusCode into the value returned
+// by newBody, e.g. func() any { return &ValidationErrors{} }, with the codec
+// matching their Content-Type. The value is set as Response.Error.
+func (client *Client) DecodeStatus(statusCode int, newBody func() any) Client {
+	client.status.decodeStatus(statusCode, newBody)
+	return *client
+}
+
+// SetErrorType decodes 4xx and 5xx bodies into the error returned by
+// newError, e.g. func() error { return &ApiError{} }, and fails the request
+// with it. Bodies that cannot be decoded fail with a *StatusError instead.
+// Types set with DecodeStatus take precedence.
+func (client *Client) SetErrorType(newError func() error) Client {
+	client.status.errorType = newError
+	return *client
+}
+
+// OnStatus calls handler for responses with statusCode, over the handlers of
+// the client.
+func (request *Request) OnStatus(statusCode int, handler StatusHandler) *Request {
+	request.status.onStatus(statusCode, handler)
+	return request
+}
+
+// OnStatusClass calls handler for responses whose status is in class, over
+// the handlers of the client.
+func (request *Request) OnStatusClass(class StatusClass, handler StatusHandler) *Request {
+	request.status.onStatusClass(class, handler)
+	return request
+}
+
+// Expect replaces the status codes expected by the client for this request.
+func (request *Request) Expect(statusCodes ...int) *Request {
+	request.status.expected = statusCodes
+	return request
+}
+
+// DecodeStatus decodes error bodies with statusCode into the value returned
+// by newBody, over the types of the client.
+func (request *Request) DecodeStatus(statusCode int, newBody func() any) *Request {
+	request.status.decodeStatus(statusCode, newBody)
+	return request
+}
+
+// WithErrorType overrides the error type of the client for this request.
+func (request *Request) WithErrorType(newError func() error) *Request {
+	request.status.errorType = newError
+	return request
+}
+
+func (request *Request) errorType() func() error {
+	if request.status.errorType != nil {
+		return request.status.errorType
+	}
+	return request.client.status.errorType
+}
+
+func (request *Request) statusHandler(statusCode int) StatusHandler {
+	class := StatusClass(statusCode / 100)
+	for _, handler := range []StatusHandler{
+		request.status.handlers[statusCode],
+		request.client.status.handlers[statusCode],
+		request.status.classHandlers[class],
+		request.client.status.classHandlers[class],
+	} {
+		if handler != nil {
+			return handler
+		}
+	}
+	return nil
+}
+
+// decodeError decodes an error body into the type registered for its status,
+// or into a generic value. Bodies that do not decode are kept as a string.
+func (request *Request) decodeError(statusCode int, contentType string, body []byte) interface{} {
+	newBody := request.status.types[statusCode]
+	if newBody == nil {
+		newBody = request.client.status.types[statusCode]
+	}
+	if newBody == nil && statusCode >= 400 {
+		if newError := request.errorType(); newError != nil {
+			newBody = func() any { return newError() }
+		}
+	}
+
+	if newBody != nil && len(body) > 0 {
+		decoded := newBody()
+		if request.client.codec(contentType).Unmarshal(body, decoded) == nil {
+			return decoded
+		}
+	}
+	return decodeError(request.client, contentType, body)
+}
+
+// handleStatus runs the status handler of the response, then checks that its
+// status is expected, or otherwise not an error with an error type.
+func (request *Request) handleStatus(res *Response) (*Response, error) {
+	if handler := request.statusHandler(res.StatusCode); handler != nil {
+		if err := handler(res); err != nil {
+			return nil, err
+		}
+		return res, nil
+	}
+
+	expected := request.status.expected
+	if expected == nil {
+		expected = request.client.status.expected
+	}
+	if slices.Contains(expected, res.StatusCode) {
+		return res, nil
+	}
+	if res.StatusCode >= 400 && request.errorType() != nil {
+		if err, ok := res.Error.(error); ok {
+			return nil, err
+		}
+		return nil, &StatusError{StatusCode: res.StatusCode, Body: res.Error, Response: res}
+	}
+	if len(expected) > 0 {
+		return nil, &StatusError{StatusCode: res.StatusCode, Body: res.Error, Response: res}
+	}
+	return res, nil
+}
+
+// statusError reads a response that a streaming helper cannot use, and returns
+// the error of its status handler or error type, or a *StatusError.
+func (request *Request) statusError(response *http.Response) error {
+	res, err := request.receive(response, nil)
+	if err != nil {
+		return err
+	}
+	return &StatusError{StatusCode: res.StatusCode, Body: res.Error, Response: res}
+}
@@ -0,0 +1,190 @@
+package ask
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type ValidationErrors struct {
+	Errors map[string]string `json:"errors"`
+}
+
+var errNotFound = errors.New("not found")
+
+// statusServer answers /<code> with that status code and a JSON body.
+func statusServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		code, _ := strconv.Atoi(r.URL.Path[1:])
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		_, _ = w.Write([]byte(`{"errors":{"title":"required"}}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestStatusHandlers(t *testing.T) {
+	server := statusServer(t)
+	var serverErrors int
+	c := NewClient(context.Background())
+	c.OnStatus(http.StatusNotFound, func(*Response) error { return errNotFound })
+	c.OnStatusClass(Status5xx, func(res *Response) error {
+		serverErrors++
+		return nil
+	})
+
+	send := func(path string) (*Response, error) {
+		request := NewRequest(http.MethodGet, server.URL+path)
+		request.setClient(c)
+		return request.Send()
+	}
+
+	_, err := send("/404")
+	assert.ErrorIs(t, err, errNotFound)
+	res, err := send("/503")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+	assert.Equal(t, 1, serverErrors)
+
+	request := NewRequest(http.MethodGet, server.URL+"/404")
+	request.setClient(c)
+	res, err = request.OnStatus(http.StatusNotFound, func(*Response) error { return nil }).Send()
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
+
+func TestStatusExpect(t *testing.T) {
+	server := statusServer(t)
+	c := NewClient(context.Background())
+	c.Expect(http.StatusOK, http.StatusCreated)
+
+	request := NewRequest(http.MethodGet, server.URL+"/201")
+	request.setClient(c)
+	_, err := request.Send()
+	assert.NoError(t, err)
+
+	request = NewRequest(http.MethodGet, server.URL+"/400")
+	request.setClient(c)
+	_, err = request.Send()
+	var statusErr *StatusError
+	assert.True(t, errors.As(err, &statusErr))
+	assert.Equal(t, http.StatusBadRequest, statusErr.StatusCode)
+	assert.Equal(t, "application/json", statusErr.Response.ContentType())
+
+	request = NewRequest(http.MethodGet, server.URL+"/400")
+	request.setClient(c)
+	_, err = request.Expect(http.StatusBadRequest).Send()
+	assert.NoError(t, err)
+}
+
+func TestDecodeStatus(t *testing.T) {
+	server := statusServer(t)
+	c := NewClient(context.Background())
+	c.DecodeStatus(http.StatusUnprocessableEntity, func() any { return &ValidationErrors{} })
+
+	request := NewRequest(http.MethodGet, server.URL+"/422")
+	request.setClient(c)
+	res, err := request.Send()
+	assert.NoError(t, err)
+	assert.Equal(t, &ValidationErrors{Errors: map[string]string{"title": "required"}}, res.Error)
+
+	request = NewRequest(http.MethodGet, server.URL+"/422")
+	request.setClient(c)
+	_, err = request.Expect(http.StatusOK).Send()
+	var statusErr *StatusError
+	assert.True(t, errors.As(err, &statusErr))
+	assert.IsType(t, &ValidationErrors{}, statusErr.Body)
+
+	request = NewRequest(http.MethodGet, server.URL+"/409")
+	request.setClient(c)
+	res, err = request.Send()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"errors": map[string]interface{}{"title": "required"}}, res.Error)
+}
+
+type ApiError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (err *ApiError) Error() string {
+	return err.Code + ": " + err.Message
+}
+
+func TestErrorType(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/html":
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte(`<html>bad gateway</html>`))
+		case "/missing":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"code":"not_found","message":"no such post"}`))
+		default:
+			_, _ = w.Write([]byte(`{"title":"post"}`))
+		}
+	}))
+	defer server.Close()
+
+	c := NewClient(context.Background())
+	c.SetErrorType(func() error { return &ApiError{} })
+	useClient(t, c)
+
+	_, err := GetJson(server.URL+"/missing", nil)
+	var apiErr *ApiError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, &ApiError{Code: "not_found", Message: "no such post"}, apiErr)
+
+	_, err = GetJson(server.URL+"/html", nil)
+	var statusErr *StatusError
+	assert.True(t, errors.As(err, &statusErr))
+	assert.Equal(t, http.StatusBadGateway, statusErr.StatusCode)
+	assert.Equal(t, "<html>bad gateway</html>", statusErr.Body)
+
+	var post Post
+	_, err = GetJson(server.URL+"/post", &post)
+	assert.NoError(t, err)
+	assert.Equal(t, "post", post.Title)
+
+	request := NewRequest(http.MethodGet, server.URL+"/missing")
+	request.setClient(c)
+	res, err := request.Expect(http.StatusNotFound).Send()
+	assert.NoError(t, err)
+	assert.Equal(t, &ApiError{Code: "not_found", Message: "no such post"}, res.Error)
+
+	type otherError struct{ ApiError }
+	request = NewRequest(http.MethodGet, server.URL+"/missing")
+	request.setClient(c)
+	_, err = request.WithErrorType(func() error { return &otherError{} }).Send()
+	assert.IsType(t, &otherError{}, err)
+}
+
+func TestMalformedErrorBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{oops}`))
+	}))
+	defer server.Close()
+	c := NewClient(context.Background())
+
+	res, err := c.NewRequest(http.MethodGet, server.URL).Send()
+	assert.NoError(t, err)
+	assert.Equal(t, "{oops}", res.Error)
+
+	_, err = c.NewRequest(http.MethodGet, server.URL).Expect(http.StatusOK).Send()
+	var statusErr *StatusError
+	assert.True(t, errors.As(err, &statusErr))
+	assert.Equal(t, "{oops}", statusErr.Body)
+
+	request := c.NewRequest(http.MethodGet, server.URL)
+	res, err = request.DecodeStatus(http.StatusBadRequest, func() any { return &ValidationErrors{} }).Send()
+	assert.NoError(t, err)
+	assert.Equal(t, "{oops}", res.Error)
+}
@@ -0,0 +1,212 @@
+package ask
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+)
+
+const (
+	maxStreamLineSize = 16 << 20
+	recordSeparator   = 0x1e
+)
+
+// LineError reports a record of a line-delimited stream that could not be
+// decoded. Line counts records from 1.
+type LineError struct {
+	Line int
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("ask: line %d: %v", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+type StreamOption func(*streamConfig)
+
+type streamConfig struct {
+	skipBadLines bool
+	jsonSeq      bool
+	maxLineSize  int
+}
+
+// SkipBadLines keeps reading after a record fails to decode. The *LineError is
+// still yielded so it can be logged.
+func SkipBadLines() StreamOption {
+	return func(config *streamConfig) {
+		config.skipBadLines = true
+	}
+}
+
+// JsonSeq reads RFC 7464 JSON text sequences, whose records are prefixed by a
+// record separator, instead of newline-delimited JSON.
+func JsonSeq() StreamOption {
+	return func(config *streamConfig) {
+		config.jsonSeq = true
+	}
+}
+
+// MaxLineSize fails the stream when a single record is longer than n bytes.
+func MaxLineSize(n int) StreamOption {
+	return func(config *streamConfig) {
+		config.maxLineSize = n
+	}
+}
+
+// Stream requests url with the global client and decodes the NDJSON or
+// json-seq response body record by record.
+func Stream[T any](ctx context.Context, url string, options ...StreamOption) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		request, response, body, err := openStream(ctx, url, "application/x-ndjson", "application/jsonl", "application/json-seq")
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		defer body.Close()
+
+		if mediaType(response.Header.Get("Content-Type")) == "application/json-seq" {
+			options = append(options, JsonSeq())
+		}
+		if limit := request.maxBodySize(); limit > 0 && limit < maxStreamLineSize {
+			options = append([]StreamOption{MaxLineSize(int(limit))}, options...)
+		}
+
+		for item, err := range DecodeStream[T](body, options...) {
+			if !yield(item, err) {
+				return
+			}
+		}
+	}
+}
+
+// openStream sends a GET request with the global client and returns its
+// decompressed body, without the size limit that applies to buffered bodies.
+func openStream(ctx context.Context, url string, accept ...string) (*Request, *http.Response, io.ReadCloser, error) {
+	request := NewRequest(http.MethodGet, url)
+	request.setClient(&client)
+	request.Accept(accept...)
+	request.streaming = true
+
+	response, err := request.WithContext(ctx).SendRaw()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if response.Body == nil {
+		response.Body = http.NoBody
+	}
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return nil, nil, nil, request.statusError(response)
+	}
+
+	body, err := decodedBody(response, 0)
+	if err != nil {
+		response.Body.Close()
+		return nil, nil, nil, err
+	}
+	return request, response, body, nil
+}
+
+// DecodeStream decodes newline-delimited JSON records from r, one per line.
+func DecodeStream[T any](r io.Reader, options ...StreamOption) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		config := streamConfig{maxLineSize: maxStreamLineSize}
+		for _, option := range options {
+			option(&config)
+		}
+
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, min(4096, config.maxLineSize)), config.maxLineSize)
+		if config.jsonSeq {
+			scanner.Split(scanJsonSeq)
+		}
+
+		line := 0
+		for scanner.Scan() {
+			record := bytes.TrimSpace(scanner.Bytes())
+			line++
+			if len(record) == 0 {
+				continue
+			}
+
+			var item T
+			err := json.Unmarshal(record, &item)
+			if err != nil {
+				if !yield(item, &LineError{Line: line, Err: err}) || !config.skipBadLines {
+					return
+				}
+				continue
+			}
+			if !yield(item, nil) {
+				return
+			}
+		}
+
+		if err := scanner.Err(); err != nil {
+			var zero T
+			yield(zero, &LineError{Line: line + 1, Err: err})
+		}
+	}
+}
+
+func scanJsonSeq(data []byte, atEOF bool) (int, []byte, error) {
+	start := 0
+	for start < len(data) && data[start] == recordSeparator {
+		start++
+	}
+	if i := bytes.IndexByte(data[start:], recordSeparator); i >= 0 {
+		return start + i, data[start : start+i], nil
+	}
+	if atEOF {
+		if start == len(data) {
+			return len(data), nil, nil
+		}
+		return len(data), data[start:], nil
+	}
+	return start, nil, nil
+}
+
+// JsonLines encodes the values of seq as newline-delimited JSON while the
+// returned reader is consumed, so it can be sent as a streaming request body.
+// The encoding goroutine runs until seq ends or the reader is closed: a
+// reader not sent as a body must be closed.
+func JsonLines[T any](seq iter.Seq[T]) io.ReadCloser {
+	reader, writer := io.Pipe()
+	go func() {
+		buffered := bufio.NewWriter(writer)
+		encoder := json.NewEncoder(buffered)
+		var err error
+		for v := range seq {
+			err = encoder.Encode(v)
+			if err != nil {
+				break
+			}
+		}
+		if err == nil {
+			err = buffered.Flush()
+		}
+		writer.CloseWithError(err)
+	}()
+	return reader
+}
+
+// ChanSeq adapts a channel to an iterator that ends when the channel is closed.
+func ChanSeq[T any](ch <-chan T) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for v := range ch {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package ask
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"strconv"
+	"strings"
+)
+
+var ErrPathNotFound = errors.New("ask: json path not found")
+
+type pathSegment struct {
+	key   string
+	index int
+}
+
+// parseJsonPath parses the subset of JSONPath used to locate an array:
+// $.data.items, $['data']['items'] and $.pages[0].items.
+func parseJsonPath(path string) ([]pathSegment, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("ask: json path %q must start with $", path)
+	}
+
+	var segments []pathSegment
+	rest := path[1:]
+	for len(rest) > 0 {
+		switch {
+		case rest[0] == '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("ask: empty key in json path %q", path)
+			}
+			segments = append(segments, pathSegment{key: rest[:end], index: -1})
+			rest = rest[end:]
+		case strings.HasPrefix(rest, "['"):
+			end := strings.Index(rest, "']")
+			if end < 0 {
+				return nil, fmt.Errorf("ask: unterminated key in json path %q", path)
+			}
+			segments = append(segments, pathSegment{key: rest[2:end], index: -1})
+			rest = rest[end+2:]
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("ask: unterminated index in json path %q", path)
+			}
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("ask: invalid index in json path %q", path)
+			}
+			segments = append(segments, pathSegment{index: index})
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("ask: invalid json path %q", path)
+		}
+	}
+	return segments, nil
+}
+
+// StreamArray requests url with the global client and yields the elements of
+// the array found at path, e.g. $.data.items, decoding one element at a time.
+func StreamArray[T any](ctx context.Context, url string, path string) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		_, _, body, err := openStream(ctx, url, "application/json")
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		defer body.Close()
+
+		for item, err := range DecodeArray[T](body, path) {
+			if !yield(item, err) {
+				return
+			}
+		}
+	}
+}
+
+// DecodeArray yields the elements of the JSON array found at path in r. Values
+// outside the path are skipped token by token, so they are never held in
+// memory as a whole.
+func DecodeArray[T any](r io.Reader, path string) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		segments, err := parseJsonPath(path)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+
+		decoder := json.NewDecoder(r)
+		found, err := seekJsonPath(decoder, segments)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		if !found {
+			yield(zero, fmt.Errorf("%w: %s", ErrPathNotFound, path))
+			return
+		}
+
+		token, err := decoder.Token()
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		if token == nil {
+			return
+		}
+		if token != json.Delim('[') {
+			yield(zero, fmt.Errorf("ask: json path %s is not an array", path))
+			return
+		}
+
+		for decoder.More() {
+			var item T
+			err := decoder.Decode(&item)
+			if !yield(item, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// seekJsonPath advances the decoder until the next value is the one addressed
+// by segments.
+func seekJsonPath(decoder *json.Decoder, segments []pathSegment) (bool, error) {
+	for _, segment := range segments {
+		token, err := decoder.Token()
+		if err != nil {
+			return false, err
+		}
+
+		if segment.index < 0 {
+			if token != json.Delim('{') {
+				return false, nil
+			}
+			found := false
+			for decoder.More() {
+				key, err := decoder.Token()
+				if err != nil {
+					return false, err
+				}
+				if key == segment.key {
+					found = true
+					break
+				}
+				if err := skipJsonValue(decoder); err != nil {
+					return false, err
+				}
+			}
+			if !found {
+				return false, nil
+			}
+			continue
+		}
+
+		if token != json.Delim('[') {
+			return false, nil
+		}
+		for i := 0; i < segment.index; i++ {
+			if !decoder.More() {
+				return false, nil
+			}
+			if err := skipJsonValue(decoder); err != nil {
+				return false, err
+			}
+		}
+		if !decoder.More() {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func skipJsonValue(decoder *json.Decoder) error {
+	depth := 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
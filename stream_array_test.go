@@ -0,0 +1,77 @@
+package ask
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamArray(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"meta":{"skip":[1,{"a":[2]}]},"data":{"total":3,"items":[`)
+		for i := 1; i <= 3; i++ {
+			if i > 1 {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprintf(w, `{"id":%d,"title":"post %d"}`, i, i)
+		}
+		fmt.Fprint(w, `]},"trailer":true}`)
+	}))
+	defer server.Close()
+
+	useClient(t, NewClient(context.Background()))
+
+	var posts []Post
+	for post, err := range StreamArray[Post](context.Background(), server.URL, "$.data.items") {
+		if err != nil {
+			t.Fatal(err)
+		}
+		posts = append(posts, post)
+	}
+
+	assert.Len(t, posts, 3)
+	assert.Equal(t, "post 3", posts[2].Title)
+}
+
+func TestDecodeArrayPaths(t *testing.T) {
+	input := `{"pages":[{"items":[1,2]},{"items":[3,4]}],"empty":null}`
+
+	cases := []struct {
+		path  string
+		value []int
+	}{
+		{"$.pages[1].items", []int{3, 4}},
+		{"$['pages'][0]['items']", []int{1, 2}},
+		{"$.empty", nil},
+	}
+	for _, c := range cases {
+		var values []int
+		for v, err := range DecodeArray[int](strings.NewReader(input), c.path) {
+			if err != nil {
+				t.Fatal(c.path, err)
+			}
+			values = append(values, v)
+		}
+		assert.Equal(t, c.value, values, c.path)
+	}
+
+	var values []int
+	for v, err := range DecodeArray[int](strings.NewReader("[5,6]"), "$") {
+		assert.NoError(t, err)
+		values = append(values, v)
+	}
+	assert.Equal(t, []int{5, 6}, values)
+
+	for _, err := range DecodeArray[int](strings.NewReader(input), "$.pages[2].items") {
+		assert.True(t, errors.Is(err, ErrPathNotFound))
+	}
+	for _, err := range DecodeArray[int](strings.NewReader(input), "pages") {
+		assert.Error(t, err)
+	}
+}
@@ -0,0 +1,186 @@
+package ask
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamNdjson(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		for i := 1; i <= 1000; i++ {
+			fmt.Fprintf(w, "{\"id\":%d}\r\n", i)
+		}
+	}))
+	defer server.Close()
+
+	useClient(t, NewClient(context.Background()))
+
+	count := 0
+	for post, err := range Stream[Post](context.Background(), server.URL) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		count++
+		assert.Equal(t, count, post.Id)
+	}
+	assert.Equal(t, 1000, count)
+}
+
+func TestStreamJsonSeq(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json-seq")
+		fmt.Fprint(w, "\x1e{\"id\":1}\n\x1e{\"id\":\n2}\n\x1e\x1e{\"id\":3}\n")
+	}))
+	defer server.Close()
+
+	useClient(t, NewClient(context.Background()))
+
+	var ids []int
+	for post, err := range Stream[Post](context.Background(), server.URL) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, post.Id)
+	}
+	assert.Equal(t, []int{1, 2, 3}, ids)
+}
+
+func TestDecodeStreamBadLines(t *testing.T) {
+	input := "{\"id\":1}\n\nnot json\n{\"id\":3}\n"
+
+	var lineErr *LineError
+	var ids []int
+	for post, err := range DecodeStream[Post](strings.NewReader(input)) {
+		if err != nil {
+			assert.True(t, errors.As(err, &lineErr))
+			continue
+		}
+		ids = append(ids, post.Id)
+	}
+	assert.Equal(t, []int{1}, ids)
+	assert.Equal(t, 3, lineErr.Line)
+
+	ids = nil
+	var skipped int
+	for post, err := range DecodeStream[Post](strings.NewReader(input), SkipBadLines()) {
+		if err != nil {
+			skipped++
+			continue
+		}
+		ids = append(ids, post.Id)
+	}
+	assert.Equal(t, []int{1, 3}, ids)
+	assert.Equal(t, 1, skipped)
+}
+
+func TestDecodeStreamMaxLineSize(t *testing.T) {
+	input := "{\"id\":1}\n{\"title\":\"" + strings.Repeat("a", 64) + "\"}\n"
+
+	var ids []int
+	var err error
+	for post, e := range DecodeStream[Post](strings.NewReader(input), MaxLineSize(32)) {
+		if e != nil {
+			err = e
+			continue
+		}
+		ids = append(ids, post.Id)
+	}
+	assert.Equal(t, []int{1}, ids)
+	assert.ErrorIs(t, err, bufio.ErrTooLong)
+}
+
+func TestJsonLinesClose(t *testing.T) {
+	done := make(chan struct{})
+	seq := func(yield func(Post) bool) {
+		defer close(done)
+		for i := 1; yield(Post{Id: i}); i++ {
+		}
+	}
+
+	reader := JsonLines[Post](seq)
+	assert.NoError(t, reader.Close())
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("encoding goroutine still running after Close")
+	}
+}
+
+func TestJsonLinesRequestBody(t *testing.T) {
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/x-ndjson", r.Header.Get("Content-Type"))
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			received = append(received, scanner.Text())
+		}
+	}))
+	defer server.Close()
+
+	posts := make(chan Post)
+	go func() {
+		for i := 1; i <= 3; i++ {
+			posts <- Post{Id: i}
+		}
+		close(posts)
+	}()
+
+	request := NewRequest(http.MethodPost, server.URL)
+	response, err := request.WithPayloadStream("application/x-ndjson", JsonLines(ChanSeq(posts))).Send()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, []string{`{"id":1,"userId":0}`, `{"id":2,"userId":0}`, `{"id":3,"userId":0}`}, received)
+}
+
+func TestStreamErrorType(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"code":"forbidden","message":"no export for you"}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(context.Background())
+	c.SetErrorType(func() error { return &ApiError{} })
+	useClient(t, c)
+
+	var errs []error
+	for _, err := range Stream[Post](context.Background(), server.URL) {
+		errs = append(errs, err)
+	}
+	assert.Equal(t, []error{&ApiError{Code: "forbidden", Message: "no export for you"}}, errs)
+}
+
+// nilBodyClient answers every request with a body-less 200 response.
+type nilBodyClient struct{}
+
+func (nilBodyClient) Do(req *http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Request: req}, nil
+}
+
+func TestStreamNilBody(t *testing.T) {
+	c := NewClient(context.Background())
+	c.SetHttpClient(nilBodyClient{})
+	useClient(t, c)
+
+	for _, err := range Stream[Post](context.Background(), "http://example.com/posts") {
+		t.Fatal(err)
+	}
+	for _, err := range StreamArray[Post](context.Background(), "http://example.com/posts", "$") {
+		assert.ErrorIs(t, err, io.EOF)
+	}
+}
@@ -0,0 +1,133 @@
+package ask
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timings breaks down where the time of a request went.
+type Timings struct {
+	DNS          time.Duration
+	Connect      time.Duration
+	TLSHandshake time.Duration
+	// FirstByte is the time from sending the request to the first byte of the
+	// response.
+	FirstByte    time.Duration
+	BodyTransfer time.Duration
+	Total        time.Duration
+	ConnReused   bool
+}
+
+func (timings Timings) String() string {
+	return fmt.Sprintf("dns=%s connect=%s tls=%s ttfb=%s body=%s total=%s reused=%t",
+		timings.DNS, timings.Connect, timings.TLSHandshake, timings.FirstByte, timings.BodyTransfer, timings.Total, timings.ConnReused)
+}
+
+// timingTrace collects Timings from httptrace events. Hedged attempts share it,
+// hence the mutex.
+type timingTrace struct {
+	mu           sync.Mutex
+	start        time.Time
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+	timings      Timings
+}
+
+func newTimingTrace() *timingTrace {
+	return &timingTrace{start: time.Now()}
+}
+
+func (trace *timingTrace) clientTrace() *httptrace.ClientTrace {
+	record := func(fn func(now time.Time)) {
+		now := time.Now()
+		trace.mu.Lock()
+		fn(now)
+		trace.mu.Unlock()
+	}
+
+	return &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			record(func(time.Time) { trace.timings.ConnReused = info.Reused })
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			record(func(now time.Time) { trace.dnsStart = now })
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			record(func(now time.Time) { trace.timings.DNS = now.Sub(trace.dnsStart) })
+		},
+		ConnectStart: func(string, string) {
+			record(func(now time.Time) { trace.connectStart = now })
+		},
+		ConnectDone: func(string, string, error) {
+			record(func(now time.Time) { trace.timings.Connect = now.Sub(trace.connectStart) })
+		},
+		TLSHandshakeStart: func() {
+			record(func(now time.Time) { trace.tlsStart = now })
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			record(func(now time.Time) { trace.timings.TLSHandshake = now.Sub(trace.tlsStart) })
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			record(func(now time.Time) { trace.wroteRequest = now })
+		},
+		GotFirstResponseByte: func() {
+			record(func(now time.Time) { trace.firstByte = now })
+		},
+	}
+}
+
+// responded marks the response headers as received, for clients that do not
+// report httptrace events, like mocks.
+func (trace *timingTrace) responded() {
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+	if trace.firstByte.IsZero() {
+		trace.firstByte = time.Now()
+	}
+}
+
+// copyFrom takes the connection timings of a call made by another trace, like
+// a call shared by deduplicated requests.
+func (trace *timingTrace) copyFrom(other *timingTrace) {
+	if trace == nil {
+		return
+	}
+	other.mu.Lock()
+	timings, wroteRequest, firstByte := other.timings, other.wroteRequest, other.firstByte
+	other.mu.Unlock()
+
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+	trace.timings = timings
+	trace.wroteRequest = wroteRequest
+	trace.firstByte = firstByte
+}
+
+// finish returns the timings once the body has been read.
+func (trace *timingTrace) finish() Timings {
+	if trace == nil {
+		return Timings{}
+	}
+
+	now := time.Now()
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+
+	timings := trace.timings
+	sent := trace.wroteRequest
+	if sent.IsZero() {
+		sent = trace.start
+	}
+	if !trace.firstByte.IsZero() {
+		timings.FirstByte = trace.firstByte.Sub(sent)
+		timings.BodyTransfer = now.Sub(trace.firstByte)
+	}
+	timings.Total = now.Sub(trace.start)
+	return timings
+}
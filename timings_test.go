@@ -0,0 +1,44 @@
+package ask
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseTimings(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"title":"timed"}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(context.Background())
+	c.SetHttpClient(server.Client())
+	useClient(t, c)
+
+	var post Post
+	res, err := GetJson(server.URL, &post)
+	if err != nil {
+		t.Fatal(err)
+	}
+	timings := res.Timings
+	assert.False(t, timings.ConnReused)
+	assert.Greater(t, timings.Connect, time.Duration(0))
+	assert.Greater(t, timings.TLSHandshake, time.Duration(0))
+	assert.GreaterOrEqual(t, timings.FirstByte, 10*time.Millisecond)
+	assert.GreaterOrEqual(t, timings.Total, timings.FirstByte+timings.BodyTransfer)
+
+	res, err = GetJson(server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, res.Timings.ConnReused)
+	assert.Zero(t, res.Timings.TLSHandshake)
+	assert.Contains(t, res.Timings.String(), "reused=true")
+}
@@ -0,0 +1,217 @@
+package ask
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+var ErrNoCertificates = errors.New("ask: no certificates found in PEM data")
+
+func (config *transportConfig) tls() *tls.Config {
+	if config.transport.TLSClientConfig == nil {
+		config.transport.TLSClientConfig = &tls.Config{}
+	}
+	return config.transport.TLSClientConfig
+}
+
+// WithCaFile trusts the CA certificates of a PEM bundle, in addition to the
+// system roots.
+func WithCaFile(path string) ClientOption {
+	return func(config *transportConfig) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			config.fail(fmt.Errorf("ask: cannot read CA bundle: %w", err))
+			return
+		}
+		WithCaPem(data)(config)
+	}
+}
+
+// WithCaPem trusts the CA certificates of PEM data, in addition to the system
+// roots.
+func WithCaPem(data []byte) ClientOption {
+	return func(config *transportConfig) {
+		tlsConfig := config.tls()
+		if tlsConfig.RootCAs == nil {
+			roots, err := x509.SystemCertPool()
+			if err != nil {
+				roots = x509.NewCertPool()
+			}
+			tlsConfig.RootCAs = roots
+		}
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(data) {
+			config.fail(ErrNoCertificates)
+		}
+	}
+}
+
+// WithClientCert authenticates with the certificate and key of PEM files,
+// for mutual TLS. An encrypted key is decrypted with password. The files are
+// read again when they change, so rotated certificates are used by new
+// connections without restarting.
+func WithClientCert(certFile string, keyFile string, password string) ClientOption {
+	return func(config *transportConfig) {
+		reloader := &certReloader{certFile: certFile, keyFile: keyFile, password: password}
+		if _, err := reloader.certificate(); err != nil {
+			config.fail(err)
+			return
+		}
+		config.tls().GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return reloader.certificate()
+		}
+	}
+}
+
+// WithClientCertPem authenticates with a PEM certificate and key, for mutual
+// TLS. An encrypted key is decrypted with password.
+func WithClientCertPem(certPem []byte, keyPem []byte, password string) ClientOption {
+	return func(config *transportConfig) {
+		cert, err := loadKeyPair(certPem, keyPem, password)
+		if err != nil {
+			config.fail(err)
+			return
+		}
+		config.tls().Certificates = []tls.Certificate{cert}
+	}
+}
+
+// WithMinTlsVersion refuses servers below version, e.g. tls.VersionTLS13.
+func WithMinTlsVersion(version uint16) ClientOption {
+	return func(config *transportConfig) {
+		config.tls().MinVersion = version
+	}
+}
+
+// WithCipherSuites restricts the TLS 1.2 cipher suites offered. TLS 1.3 suites
+// are not configurable.
+func WithCipherSuites(suites ...uint16) ClientOption {
+	return func(config *transportConfig) {
+		config.tls().CipherSuites = suites
+	}
+}
+
+// certCheckInterval is how often the files of a client certificate are
+// checked for changes.
+var certCheckInterval = time.Second
+
+// certReloader loads a key pair and reloads it when its files change.
+type certReloader struct {
+	certFile string
+	keyFile  string
+	password string
+
+	mu       sync.Mutex
+	cert     *tls.Certificate
+	certStat fileStamp
+	keyStat  fileStamp
+	checked  time.Time
+}
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func stamp(path string) (fileStamp, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}, err
+	}
+	return fileStamp{modTime: info.ModTime(), size: info.Size()}, nil
+}
+
+// certificate returns the current key pair, checking the files at most once
+// per certCheckInterval. When the files are being rotated and cannot be
+// loaded, the previous pair keeps being used.
+func (reloader *certReloader) certificate() (*tls.Certificate, error) {
+	reloader.mu.Lock()
+	defer reloader.mu.Unlock()
+
+	now := time.Now()
+	if reloader.cert != nil && now.Sub(reloader.checked) < certCheckInterval {
+		return reloader.cert, nil
+	}
+	reloader.checked = now
+
+	certStat, certErr := stamp(reloader.certFile)
+	keyStat, keyErr := stamp(reloader.keyFile)
+	if reloader.cert != nil && (certErr != nil || keyErr != nil || (certStat == reloader.certStat && keyStat == reloader.keyStat)) {
+		return reloader.cert, nil
+	}
+
+	cert, err := reloader.load()
+	if err != nil {
+		if reloader.cert != nil {
+			return reloader.cert, nil
+		}
+		return nil, err
+	}
+	reloader.cert = &cert
+	reloader.certStat = certStat
+	reloader.keyStat = keyStat
+	return reloader.cert, nil
+}
+
+func (reloader *certReloader) load() (tls.Certificate, error) {
+	certPem, err := os.ReadFile(reloader.certFile)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("ask: cannot read client certificate: %w", err)
+	}
+	keyPem, err := os.ReadFile(reloader.keyFile)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("ask: cannot read client key: %w", err)
+	}
+	return loadKeyPair(certPem, keyPem, reloader.password)
+}
+
+func loadKeyPair(certPem []byte, keyPem []byte, password string) (tls.Certificate, error) {
+	if password != "" {
+		var err error
+		keyPem, err = decryptKeyPem(keyPem, []byte(password))
+		if err != nil {
+			return tls.Certificate{}, err
+		}
+	}
+
+	cert, err := tls.X509KeyPair(certPem, keyPem)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("ask: invalid client certificate: %w", err)
+	}
+	return cert, nil
+}
+
+// decryptKeyPem decrypts the first private key of PEM data, either a PKCS #8
+// "ENCRYPTED PRIVATE KEY" or a legacy OpenSSL key with a Proc-Type header.
+func decryptKeyPem(data []byte, password []byte) ([]byte, error) {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, errors.New("ask: no private key found in PEM data")
+		}
+
+		switch {
+		case block.Type == "ENCRYPTED PRIVATE KEY":
+			der, err := decryptPkcs8(block.Bytes, password)
+			if err != nil {
+				return nil, err
+			}
+			return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+		case x509.IsEncryptedPEMBlock(block):
+			// Deprecated for its weak format, but such keys are still common.
+			der, err := x509.DecryptPEMBlock(block, password)
+			if err != nil {
+				return nil, fmt.Errorf("ask: cannot decrypt private key: %w", err)
+			}
+			return pem.EncodeToMemory(&pem.Block{Type: block.Type, Bytes: der}), nil
+		case block.Type == "PRIVATE KEY" || block.Type == "RSA PRIVATE KEY" || block.Type == "EC PRIVATE KEY":
+			return pem.EncodeToMemory(block), nil
+		}
+	}
+}
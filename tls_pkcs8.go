@@ -0,0 +1,118 @@
+package ask
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"hash"
+)
+
+var errUnsupportedKeyEncryption = errors.New("ask: unsupported private key encryption, only PBES2 with PBKDF2 and AES-CBC is supported")
+
+var (
+	oidPbes2        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPbkdf2       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHmacSha1     = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHmacSha256   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidAes128Cbc    = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAes192Cbc    = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAes256Cbc    = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	aesCbcKeyLength = map[string]int{oidAes128Cbc.String(): 16, oidAes192Cbc.String(): 24, oidAes256Cbc.String(): 32}
+)
+
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	Prf            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// decryptPkcs8 decrypts a PKCS #8 EncryptedPrivateKeyInfo, as written by
+// "openssl pkcs8 -topk8", and returns the PKCS #8 private key.
+func decryptPkcs8(der []byte, password []byte) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, err
+	}
+	if !info.Algorithm.Algorithm.Equal(oidPbes2) {
+		return nil, errUnsupportedKeyEncryption
+	}
+
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, err
+	}
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPbkdf2) {
+		return nil, errUnsupportedKeyEncryption
+	}
+	keyLength, ok := aesCbcKeyLength[params.EncryptionScheme.Algorithm.String()]
+	if !ok {
+		return nil, errUnsupportedKeyEncryption
+	}
+
+	var kdf pbkdf2Params
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdf); err != nil {
+		return nil, err
+	}
+	prf := sha1.New
+	switch {
+	case len(kdf.Prf.Algorithm) == 0 || kdf.Prf.Algorithm.Equal(oidHmacSha1):
+	case kdf.Prf.Algorithm.Equal(oidHmacSha256):
+		prf = sha256.New
+	default:
+		return nil, errUnsupportedKeyEncryption
+	}
+
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, err
+	}
+	if len(iv) != aes.BlockSize || len(info.EncryptedData) == 0 || len(info.EncryptedData)%aes.BlockSize != 0 {
+		return nil, errors.New("ask: invalid encrypted private key")
+	}
+
+	key, err := pbkdf2Key(password, kdf.Salt, kdf.IterationCount, keyLength, prf)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	plain := make([]byte, len(info.EncryptedData))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, info.EncryptedData)
+
+	// The key is a DER sequence, whose length tells where the padding starts.
+	// A wrong password almost always shows up as an invalid key.
+	var sequence asn1.RawValue
+	padding, err := asn1.Unmarshal(plain, &sequence)
+	if err != nil || len(padding) == 0 || len(padding) > aes.BlockSize {
+		return nil, x509.IncorrectPasswordError
+	}
+	plain = sequence.FullBytes
+	if _, err := x509.ParsePKCS8PrivateKey(plain); err != nil {
+		return nil, x509.IncorrectPasswordError
+	}
+	return plain, nil
+}
+
+// pbkdf2Key derives the key of the cipher as specified by RFC 8018.
+func pbkdf2Key(password []byte, salt []byte, iterations int, keyLength int, h func() hash.Hash) ([]byte, error) {
+	return pbkdf2.Key(h, string(password), salt, iterations, keyLength)
+}
@@ -0,0 +1,248 @@
+package ask
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testCa struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCa(t *testing.T) *testCa {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCa{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a PEM certificate and PKCS #8 key signed by the CA, valid for
+// 127.0.0.1, localhost and commonName.
+func (ca *testCa) issue(t *testing.T, commonName string) ([]byte, []byte) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		DNSNames:     []string{"localhost", commonName},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, _ := x509.MarshalPKCS8PrivateKey(key)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDer})
+}
+
+// mtlsServer answers with the common name of the client certificate.
+func mtlsServer(t *testing.T, ca *testCa) *httptest.Server {
+	certPem, keyPem := ca.issue(t, "server")
+	cert, err := tls.X509KeyPair(certPem, keyPem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientCas := x509.NewCertPool()
+	clientCas.AddCert(ca.cert)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{cert}, ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCas}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server
+}
+
+// encryptPkcs8 encrypts a PKCS #8 key like "openssl pkcs8 -topk8 -v2 aes-256-cbc".
+func encryptPkcs8(t *testing.T, keyPem []byte, password string) []byte {
+	block, _ := pem.Decode(keyPem)
+	salt, iv := make([]byte, 8), make([]byte, aes.BlockSize)
+	_, _ = rand.Read(salt)
+	_, _ = rand.Read(iv)
+
+	padding := aes.BlockSize - len(block.Bytes)%aes.BlockSize
+	plain := append(append([]byte(nil), block.Bytes...), make([]byte, padding)...)
+	for i := len(block.Bytes); i < len(plain); i++ {
+		plain[i] = byte(padding)
+	}
+	key, err := pbkdf2Key([]byte(password), salt, 2048, 32, sha256.New)
+	if err != nil {
+		t.Fatal(err)
+	}
+	aesBlock, _ := aes.NewCipher(key)
+	encrypted := make([]byte, len(plain))
+	cipher.NewCBCEncrypter(aesBlock, iv).CryptBlocks(encrypted, plain)
+
+	marshal := func(v any) asn1.RawValue {
+		data, err := asn1.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return asn1.RawValue{FullBytes: data}
+	}
+	der, err := asn1.Marshal(encryptedPrivateKeyInfo{
+		Algorithm: pkix.AlgorithmIdentifier{Algorithm: oidPbes2, Parameters: marshal(pbes2Params{
+			KeyDerivationFunc: pkix.AlgorithmIdentifier{Algorithm: oidPbkdf2, Parameters: marshal(pbkdf2Params{
+				Salt:           salt,
+				IterationCount: 2048,
+				Prf:            pkix.AlgorithmIdentifier{Algorithm: oidHmacSha256, Parameters: asn1.NullRawValue},
+			})},
+			EncryptionScheme: pkix.AlgorithmIdentifier{Algorithm: oidAes256Cbc, Parameters: marshal(iv)},
+		})},
+		EncryptedData: encrypted,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: der})
+}
+
+func TestPbkdf2(t *testing.T) {
+	// RFC 6070 test vector.
+	key, err := pbkdf2Key([]byte("password"), []byte("salt"), 4096, 20, sha1.New)
+	assert.NoError(t, err)
+	assert.Equal(t, "4b007901b765489abead49d926f721d065a429c1", hex.EncodeToString(key))
+}
+
+func TestMutualTls(t *testing.T) {
+	ca := newTestCa(t)
+	server := mtlsServer(t, ca)
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	writeClientCert := func(commonName string, modTime time.Time) {
+		certPem, keyPem := ca.issue(t, commonName)
+		_ = os.WriteFile(certFile, certPem, 0600)
+		_ = os.WriteFile(keyFile, encryptPkcs8(t, keyPem, "secret"), 0600)
+		_ = os.Chtimes(certFile, modTime, modTime)
+		_ = os.Chtimes(keyFile, modTime, modTime)
+	}
+	writeClientCert("client-a", time.Now().Add(-time.Minute))
+	interval := certCheckInterval
+	certCheckInterval = 0
+	t.Cleanup(func() { certCheckInterval = interval })
+
+	c := NewClient(context.Background(),
+		WithCaPem(ca.pem),
+		WithClientCert(certFile, keyFile, "secret"),
+		WithMinTlsVersion(tls.VersionTLS12),
+		WithoutKeepAlives(),
+	)
+	res, err := c.NewRequest(http.MethodGet, server.URL).Send()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "client-a", string(*res.GetBody()))
+
+	writeClientCert("client-b", time.Now())
+	res, err = c.NewRequest(http.MethodGet, server.URL).Send()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "client-b", string(*res.GetBody()))
+
+	_, err = NewClient(context.Background(), WithCaPem(ca.pem)).NewRequest(http.MethodGet, server.URL).Send()
+	assert.Error(t, err)
+
+	_, err = NewClient(context.Background(), WithClientCert(certFile, keyFile, "secret")).NewRequest(http.MethodGet, server.URL).Send()
+	var unknownAuthority x509.UnknownAuthorityError
+	assert.ErrorAs(t, err, &unknownAuthority)
+}
+
+func TestCertReloaderCheckInterval(t *testing.T) {
+	ca := newTestCa(t)
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	writeClientCert := func(commonName string, modTime time.Time) {
+		certPem, keyPem := ca.issue(t, commonName)
+		_ = os.WriteFile(certFile, certPem, 0600)
+		_ = os.WriteFile(keyFile, keyPem, 0600)
+		_ = os.Chtimes(certFile, modTime, modTime)
+		_ = os.Chtimes(keyFile, modTime, modTime)
+	}
+	writeClientCert("client-a", time.Now().Add(-time.Minute))
+
+	reloader := &certReloader{certFile: certFile, keyFile: keyFile}
+	first, err := reloader.certificate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeClientCert("client-b", time.Now())
+	cert, _ := reloader.certificate()
+	assert.Same(t, first, cert)
+
+	reloader.checked = time.Now().Add(-certCheckInterval)
+	cert, _ = reloader.certificate()
+	assert.NotSame(t, first, cert)
+}
+
+func TestClientCertPem(t *testing.T) {
+	ca := newTestCa(t)
+	server := mtlsServer(t, ca)
+	certPem, keyPem := ca.issue(t, "legacy")
+
+	block, _ := pem.Decode(keyPem)
+	key, _ := x509.ParsePKCS8PrivateKey(block.Bytes)
+	ecDer, _ := x509.MarshalECPrivateKey(key.(*ecdsa.PrivateKey))
+	legacy, err := x509.EncryptPEMBlock(rand.Reader, "EC PRIVATE KEY", ecDer, []byte("secret"), x509.PEMCipherAES256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacyPem := pem.EncodeToMemory(legacy)
+
+	c := NewClient(context.Background(), WithCaPem(ca.pem), WithClientCertPem(certPem, legacyPem, "secret"))
+	res, err := c.NewRequest(http.MethodGet, server.URL).Send()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "legacy", string(*res.GetBody()))
+
+	_, err = NewClient(context.Background(), WithClientCertPem(certPem, encryptPkcs8(t, keyPem, "secret"), "wrong")).NewRequest(http.MethodGet, server.URL).Send()
+	assert.ErrorIs(t, err, x509.IncorrectPasswordError)
+
+	_, err = NewClient(context.Background(), WithCaPem([]byte("not a certificate"))).NewRequest(http.MethodGet, server.URL).Send()
+	assert.ErrorIs(t, err, ErrNoCertificates)
+}
@@ -0,0 +1,200 @@
+package ask
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// DefaultRequestIdHeader carries the request id from the context, unless the
+// client sets another header with SetRequestIdHeader.
+const DefaultRequestIdHeader = "X-Request-Id"
+
+var ErrInvalidTraceparent = errors.New("ask: invalid traceparent")
+
+// TraceContext is the W3C trace context of a call.
+type TraceContext struct {
+	// TraceId is 32 lowercase hex characters.
+	TraceId string
+	// SpanId is the 16 hex characters id of the parent span.
+	SpanId  string
+	Sampled bool
+	// TraceState and Baggage are forwarded as they are.
+	TraceState string
+	Baggage    string
+}
+
+// Tracer starts a span for every attempt of a request, including hedges and
+// stream reconnections, so an external tracing system can be attached. trace
+// holds the span id of the attempt; the parent span is the one of ctx.
+type Tracer interface {
+	StartSpan(ctx context.Context, req *http.Request, trace TraceContext) Span
+}
+
+// Span is ended when the response headers are received or the attempt failed.
+type Span interface {
+	End(response *http.Response, err error)
+}
+
+type traceKey struct{}
+
+type requestIdKey struct{}
+
+// ContextWithTrace returns a context carrying trace, propagated by the
+// requests sent with it.
+func ContextWithTrace(ctx context.Context, trace TraceContext) context.Context {
+	return context.WithValue(ctx, traceKey{}, trace)
+}
+
+func TraceFromContext(ctx context.Context) (TraceContext, bool) {
+	trace, ok := ctx.Value(traceKey{}).(TraceContext)
+	return trace, ok
+}
+
+// ContextWithRequestId returns a context carrying a correlation id, sent by
+// the requests made with it.
+func ContextWithRequestId(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIdKey{}, id)
+}
+
+func RequestIdFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIdKey{}).(string)
+	return id
+}
+
+// ExtractTrace reads the trace context and request id of an inbound request,
+// typically in a server middleware, into ctx. The request id is read from the
+// header set on the global client. An invalid traceparent is ignored, as the
+// specification requires.
+func ExtractTrace(ctx context.Context, header http.Header) context.Context {
+	return client.ExtractTrace(ctx, header)
+}
+
+// ExtractTrace is like the package ExtractTrace, reading the request id from
+// the header set with SetRequestIdHeader.
+func (client *Client) ExtractTrace(ctx context.Context, header http.Header) context.Context {
+	if trace, err := ParseTraceparent(header.Get("traceparent")); err == nil {
+		trace.TraceState = strings.Join(header.Values("tracestate"), ",")
+		trace.Baggage = strings.Join(header.Values("baggage"), ",")
+		ctx = ContextWithTrace(ctx, trace)
+	}
+	if id := header.Get(client.requestIdHeaderName()); id != "" {
+		ctx = ContextWithRequestId(ctx, id)
+	}
+	return ctx
+}
+
+// ParseTraceparent parses a traceparent header value.
+func ParseTraceparent(value string) (TraceContext, error) {
+	value = strings.TrimSpace(value)
+	if len(value) < 55 || (len(value) > 55 && value[55] != '-') {
+		return TraceContext{}, ErrInvalidTraceparent
+	}
+	version, traceId, spanId, flags := value[0:2], value[3:35], value[36:52], value[53:55]
+	if value[2] != '-' || value[35] != '-' || value[52] != '-' {
+		return TraceContext{}, ErrInvalidTraceparent
+	}
+	if !isLowerHex(version) || version == "ff" || (version == "00" && len(value) != 55) {
+		return TraceContext{}, ErrInvalidTraceparent
+	}
+	if !isLowerHex(traceId) || traceId == strings.Repeat("0", 32) || !isLowerHex(spanId) || spanId == strings.Repeat("0", 16) || !isLowerHex(flags) {
+		return TraceContext{}, ErrInvalidTraceparent
+	}
+
+	flagBits, _ := hex.DecodeString(flags)
+	return TraceContext{TraceId: traceId, SpanId: spanId, Sampled: flagBits[0]&1 == 1}, nil
+}
+
+// Traceparent formats the trace context as a traceparent header value.
+func (trace TraceContext) Traceparent() string {
+	flags := "00"
+	if trace.Sampled {
+		flags = "01"
+	}
+	return "00-" + trace.TraceId + "-" + trace.SpanId + "-" + flags
+}
+
+func (client *Client) SetTracer(tracer Tracer) Client {
+	client.tracer = tracer
+	return *client
+}
+
+// SetRequestIdHeader changes the header carrying the request id of the
+// context. It defaults to DefaultRequestIdHeader.
+func (client *Client) SetRequestIdHeader(name string) Client {
+	client.requestIdHeader = name
+	return *client
+}
+
+func (client *Client) requestIdHeaderName() string {
+	if client.requestIdHeader == "" {
+		return DefaultRequestIdHeader
+	}
+	return client.requestIdHeader
+}
+
+// startTrace starts a new trace when a tracer is set and ctx carries none,
+// so that all attempts of the request share it.
+func (client *Client) startTrace(ctx context.Context) context.Context {
+	if _, ok := TraceFromContext(ctx); ok || client.tracer == nil {
+		return ctx
+	}
+	return ContextWithTrace(ctx, TraceContext{TraceId: randomHex(16), Sampled: true})
+}
+
+// attempt sends a single attempt of req. The trace context is propagated with
+// a new span id, so every attempt shows up as its own span.
+func (request *Request) attempt(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	trace, traced := TraceFromContext(ctx)
+	requestId := RequestIdFromContext(ctx)
+	if !traced && requestId == "" {
+		return request.do(req)
+	}
+
+	req = req.Clone(ctx)
+	if requestId != "" {
+		header := request.client.requestIdHeaderName()
+		if req.Header.Get(header) == "" {
+			req.Header.Set(header, requestId)
+		}
+	}
+
+	if !traced {
+		return request.do(req)
+	}
+	trace.SpanId = randomHex(8)
+	req.Header.Set("traceparent", trace.Traceparent())
+	if trace.TraceState != "" {
+		req.Header.Set("tracestate", trace.TraceState)
+	}
+	if trace.Baggage != "" {
+		req.Header.Set("baggage", trace.Baggage)
+	}
+
+	if request.client.tracer == nil {
+		return request.do(req)
+	}
+	span := request.client.tracer.StartSpan(ctx, req, trace)
+	response, err := request.do(req)
+	span.End(response, err)
+	return response, err
+}
+
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !('0' <= s[i] && s[i] <= '9' || 'a' <= s[i] && s[i] <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,136 @@
+package ask
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans []TraceContext
+	ended []int
+}
+
+func (recorder *spanRecorder) StartSpan(_ context.Context, _ *http.Request, trace TraceContext) Span {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	recorder.spans = append(recorder.spans, trace)
+	return spanEnd(func(response *http.Response, err error) {
+		recorder.mu.Lock()
+		defer recorder.mu.Unlock()
+		if err == nil {
+			recorder.ended = append(recorder.ended, response.StatusCode)
+		}
+	})
+}
+
+type spanEnd func(response *http.Response, err error)
+
+func (end spanEnd) End(response *http.Response, err error) {
+	end(response, err)
+}
+
+func TestParseTraceparent(t *testing.T) {
+	trace, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	assert.NoError(t, err)
+	assert.Equal(t, TraceContext{TraceId: "4bf92f3577b34da6a3ce929d0e0e4736", SpanId: "00f067aa0ba902b7", Sampled: true}, trace)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", trace.Traceparent())
+
+	trace, err = ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-future")
+	assert.NoError(t, err)
+	assert.False(t, trace.Sampled)
+
+	for _, invalid := range []string{
+		"",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	} {
+		_, err = ParseTraceparent(invalid)
+		assert.ErrorIs(t, err, ErrInvalidTraceparent, invalid)
+	}
+}
+
+func TestTracePropagation(t *testing.T) {
+	var mu sync.Mutex
+	var headers []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		headers = append(headers, r.Header.Clone())
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	inbound := http.Header{}
+	inbound.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	inbound.Set("tracestate", "vendor=value")
+	inbound.Set("baggage", "user=42")
+	inbound.Set("X-Request-Id", "req-1")
+	ctx := ExtractTrace(context.Background(), inbound)
+
+	c := NewClient(ctx)
+	c.SetRequestIdHeader("X-Correlation-Id")
+	for i := 0; i < 2; i++ {
+		_, err := c.NewRequest(http.MethodGet, server.URL).Send()
+		assert.NoError(t, err)
+	}
+
+	assert.Len(t, headers, 2)
+	first, err := ParseTraceparent(headers[0].Get("traceparent"))
+	assert.NoError(t, err)
+	second, err := ParseTraceparent(headers[1].Get("traceparent"))
+	assert.NoError(t, err)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", first.TraceId)
+	assert.Equal(t, first.TraceId, second.TraceId)
+	assert.NotEqual(t, "00f067aa0ba902b7", first.SpanId)
+	assert.NotEqual(t, first.SpanId, second.SpanId)
+	assert.Equal(t, "vendor=value", headers[0].Get("tracestate"))
+	assert.Equal(t, "user=42", headers[0].Get("baggage"))
+	assert.Equal(t, "req-1", headers[0].Get("X-Correlation-Id"))
+	assert.Empty(t, headers[0].Get("X-Request-Id"))
+
+	_, err = NewRequest(http.MethodGet, server.URL).Send()
+	assert.NoError(t, err)
+	assert.Empty(t, headers[2].Get("traceparent"))
+}
+
+func TestClientExtractTrace(t *testing.T) {
+	inbound := http.Header{}
+	inbound.Set("X-Request-Id", "default")
+	inbound.Set("X-Correlation-Id", "custom")
+
+	c := NewClient(context.Background())
+	assert.Equal(t, "default", RequestIdFromContext(c.ExtractTrace(context.Background(), inbound)))
+	c.SetRequestIdHeader("X-Correlation-Id")
+	assert.Equal(t, "custom", RequestIdFromContext(c.ExtractTrace(context.Background(), inbound)))
+
+	useClient(t, c)
+	assert.Equal(t, "custom", RequestIdFromContext(ExtractTrace(context.Background(), inbound)))
+}
+
+func TestTracerSpansPerAttempt(t *testing.T) {
+	server, _, _ := slowFirstServer(t)
+
+	tracer := &spanRecorder{}
+	c := NewClient(context.Background())
+	c.SetTracer(tracer)
+	c.SetHedging(HedgePolicy{Delay: 10 * time.Millisecond, Budget: 1})
+
+	_, err := c.NewRequest(http.MethodGet, server.URL).Send()
+	assert.NoError(t, err)
+
+	tracer.mu.Lock()
+	defer tracer.mu.Unlock()
+	assert.Len(t, tracer.spans, 2)
+	assert.Equal(t, tracer.spans[0].TraceId, tracer.spans[1].TraceId)
+	assert.NotEqual(t, tracer.spans[0].SpanId, tracer.spans[1].SpanId)
+	assert.Equal(t, []int{http.StatusOK}, tracer.ended)
+}
@@ -2,16 +2,34 @@ package ask
 
 import "encoding/json"
 
-func marshalResponseIfStruct(response []byte, parsedBody any) error {
-	switch parsedBody.(type) {
-	case []byte:
-		parsedBody = response
-	default:
-		err := json.Unmarshal(response, &parsedBody)
-		if err != nil {
-			return err
+func decodeBody(client *Client, contentType string, body []byte, v any) error {
+	if v == nil || len(body) == 0 {
+		return nil
+	}
+	if raw, ok := v.(*[]byte); ok {
+		*raw = body
+		return nil
+	}
+
+	return client.codec(contentType).Unmarshal(body, v)
+}
+
+// decodeError decodes an error body with the codec of its Content-Type, or as
+// JSON when it looks like it, and keeps it as a string when it does not decode.
+func decodeError(client *Client, contentType string, body []byte) interface{} {
+	var decoded interface{}
+	if codec := client.lookupCodec(contentType); codec != nil && len(body) > 0 {
+		if codec.Unmarshal(body, &decoded) == nil {
+			return decoded
+		}
+	}
+
+	strBody := string(body)
+	if len(strBody) > 0 && ((strBody[0] == '{' && strBody[len(strBody)-1] == '}') || (strBody[0] == '[' && strBody[len(strBody)-1] == ']')) {
+		if json.Unmarshal(body, &decoded) == nil {
+			return decoded
 		}
 	}
 
-	return nil
+	return strBody
 }